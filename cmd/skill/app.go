@@ -1,24 +1,81 @@
 package main
 
 import (
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/i18n"
 	"bitbucket.org/sotavant/yandex-alice-skill/internal/logger"
 	"bitbucket.org/sotavant/yandex-alice-skill/internal/models"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/router"
 	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/templates"
+	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"go.uber.org/zap"
 	"net/http"
-	"strings"
 	"time"
 )
 
 type app struct {
-	store store.Store
+	store   store.Store
+	router  *router.Router
+	phrases *i18n.Bundle
+
+	confirmTimeout time.Duration
 }
 
-func newApp(s store.Store) *app {
-	return &app{store: s}
+// option задаёт необязательные настройки навыка.
+type option func(a *app)
+
+// withSendConfirmation включает подтверждение перед отправкой сообщения.
+// Неподтверждённое за timeout сообщение не отправляется.
+func withSendConfirmation(timeout time.Duration) option {
+	return func(a *app) {
+		a.confirmTimeout = timeout
+	}
+}
+
+// withRandomPhrases включает случайный выбор из вариантов каждой фразы,
+// чтобы навык не отвечал одинаково. По умолчанию выбирается первый вариант.
+func withRandomPhrases() option {
+	return func(a *app) {
+		a.phrases = newPhrases(templates.Random)
+	}
+}
+
+func newApp(s store.Store, opts ...option) *app {
+	a := &app{store: s, phrases: newPhrases(templates.First)}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	p := a.phrases
+	r := router.New()
+	r.Register(exitHandler{phrases: p})
+	r.Register(composeHandler{store: s, phrases: p, confirmTimeout: a.confirmTimeout})
+	r.Register(threadHandler{store: s, phrases: p})
+	r.Register(readHandler{store: s, phrases: p})
+	r.Register(replyHandler{store: s, phrases: p})
+	r.Register(deleteHandler{store: s, phrases: p})
+	r.Register(inboxHandler{store: s, phrases: p})
+	r.Register(registerHandler{store: s, phrases: p})
+	r.Fallback(greetingHandler{store: s, phrases: p})
+	a.router = r
+
+	return a
+}
+
+// handle выбирает обработчик для запроса и передаёт ему запрос.
+func (a *app) handle(ctx context.Context, req *models.Request, resp *models.Response) error {
+	if req.MailboxID() == "" {
+		return fmt.Errorf("%w: request has neither user nor application id", errBadRequest)
+	}
+
+	handler, err := a.router.Route(req)
+	if err != nil {
+		return err
+	}
+
+	return handler.Handle(ctx, req, resp)
 }
 
 func (a *app) webhook(w http.ResponseWriter, r *http.Request) {
@@ -40,123 +97,39 @@ func (a *app) webhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Request.Type != models.TypeSimpleUtterance {
+	if req.Request.Type != models.TypeSimpleUtterance && req.Request.Type != models.TypeButtonPressed {
 		logger.Log.Debug("usupported request type", zap.String("type", req.Request.Type))
 		w.WriteHeader(http.StatusUnprocessableEntity)
 		return
 	}
 
-	messages, err := a.store.ListMessages(ctx, req.Session.User.UserID)
-	if err != nil {
-		logger.Log.Debug("cannot load messages for user", zap.Error(err))
-		w.WriteHeader(http.StatusInternalServerError)
-		return
+	// состояние сессии и приложения перезаписывается каждым ответом,
+	// поэтому по умолчанию возвращаем то, что пришло в запросе
+	sessionState := req.State.Session
+	applicationState := req.State.Application
+	// вопрос об очистке входящих ждёт ответа одну реплику:
+	// «да», сказанное позже по другому поводу, ничего не удалит
+	sessionState.ClearInbox = false
+
+	// заполним модель ответа
+	resp := models.Response{
+		SessionState:     &sessionState,
+		ApplicationState: &applicationState,
+		Version:          models.ProtocolVersion,
 	}
 
-	// формируем текст с количеством сообщений
-	var text string
-	switch true {
-	case strings.HasPrefix(req.Request.Command, "Отправь"):
-		username, message := parseSendCommand(req.Request.Command)
-
-		recipientID, err := a.store.FindRecipient(ctx, username)
-		if err != nil {
-			logger.Log.Debug("cannot find recipient by username", zap.String("username", username), zap.Error(err))
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		err = a.store.SaveMessage(ctx, recipientID, store.Message{
-			Sender:  req.Session.User.UserID,
-			Time:    time.Now(),
-			Payload: message,
-		})
-
-		if err != nil {
-			logger.Log.Debug("cannot save message", zap.String("recipint", recipientID), zap.Error(err))
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		text = "Сообщение успешно отправлено"
-	case strings.HasPrefix(req.Request.Command, "Прочитай"):
-		messageIndex := parseReadCommand(req.Request.Command)
-
-		messages, err := a.store.ListMessages(ctx, req.Session.User.UserID)
-		if err != nil {
-			logger.Log.Debug("cannot load messages for user", zap.Error(err))
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		text = "Для вас нет новых сообщений."
-		if len(messages) < messageIndex {
-			// пользователь попросил прочитать сообщение, которого нет
-			text = "Такого сообщения не существует."
-		} else {
-			// получим сообщение по идентификатору
-			messageID := messages[messageIndex].ID
-			message, err := a.store.GetMessage(ctx, messageID)
-			if err != nil {
-				logger.Log.Debug("cannot load message", zap.Int64("id", messageID), zap.Error(err))
-				w.WriteHeader(http.StatusInternalServerError)
-				return
-			}
-
-			// передадим текст сообщения в ответе
-			text = fmt.Sprintf("Сообщение от %s, отправлено %s: %s", message.Sender, message.Time, message.Payload)
-		}
-	case strings.HasPrefix(req.Request.Command, "Зарегистрируй"):
-		username := parseRegisterCommand(req.Request.Command)
-		err := a.store.RegisterUser(ctx, req.Session.User.UserID, username)
-		if err != nil && !errors.Is(err, store.ErrConflict) {
-			logger.Log.Debug("cannot register user", zap.Error(err))
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		text = fmt.Sprintf("Вы успешно зарегистрированы под именем %s", username)
-		if errors.Is(err, store.ErrConflict) {
-			text = "Извините, такое имя уже занято. Попробуйте другое."
-		}
-	default:
-		messages, err := a.store.ListMessages(ctx, req.Session.User.UserID)
-		if err != nil {
-			logger.Log.Debug("cannot load messages for user", zap.Error(err))
-			w.WriteHeader(http.StatusInternalServerError)
-			return
-		}
-
-		text = "Для вас нет новых сообщений."
-		if len(messages) > 0 {
-			text = fmt.Sprintf("Для вас %d новых сообщений.", len(messages))
-		}
-
-		// первый запрос новой сессии
-		if req.Session.New {
-			// обработаем поле Timezone запроса
-			tz, err := time.LoadLocation(req.Timezone)
-			if err != nil {
-				logger.Log.Debug("cannot parse timezone")
-				w.WriteHeader(http.StatusBadRequest)
-				return
-			}
-
-			// получим текущее время в часовом поясе пользователя
-			now := time.Now().In(tz)
-			hour, minute, _ := now.Clock()
-
-			// формируем новый текст приветствия
-			text = fmt.Sprintf("Точное время %d часов, %d минут. %s", hour, minute, text)
-		}
+	if err := a.handle(ctx, &req, &resp); err != nil {
+		logger.Log.Debug("cannot handle request", zap.String("command", req.Request.Command), zap.Error(err))
+		// ответим фразой вместо HTTP-ошибки, состояние сессии при этом сохраняем
+		resp.Response = models.ResponsePayload{Text: speechForError(a.phrases.For(req.Meta.Locale), err)}
 	}
+	resp.Response.Adapt(req.Meta.Interfaces)
 
-	// заполним модель ответа
-	resp := models.Response{
-		Response: models.ResponsePayload{
-			Text: text, // Алиса проговорит текст
-		},
-		Version: "1.0",
+	if resp.SessionState != nil && resp.SessionState.IsZero() {
+		resp.SessionState = nil
+	}
+	if resp.ApplicationState != nil && resp.ApplicationState.IsZero() {
+		resp.ApplicationState = nil
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -0,0 +1,306 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/dialog"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/i18n"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/models"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/parser"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/templates"
+)
+
+// шаги диалога составления сообщения
+const (
+	stepAwaitingRecipient    dialog.State = "awaiting_recipient"
+	stepAwaitingText         dialog.State = "awaiting_text"
+	stepAwaitingConfirmation dialog.State = "awaiting_confirmation"
+)
+
+// события диалога составления сообщения
+const (
+	eventStart     dialog.Event = "start"
+	eventRecipient dialog.Event = "recipient"
+	eventText      dialog.Event = "text"
+	eventConfirm   dialog.Event = "confirm"
+	eventReject    dialog.Event = "reject"
+	eventCancel    dialog.Event = "cancel"
+)
+
+// composeFlow — сценарий отправки сообщения:
+// «Отправь сообщение» → «Кому?» → «Маше» → «Что передать?» → «Привет» → «Отправить?» → «Да».
+// Шаг подтверждения пропускается, если подтверждение отключено.
+var composeFlow = dialog.NewFlow(
+	dialog.Transition{From: dialog.StateIdle, Event: eventStart, To: stepAwaitingRecipient},
+	dialog.Transition{From: stepAwaitingRecipient, Event: eventRecipient, To: stepAwaitingText},
+	dialog.Transition{From: stepAwaitingText, Event: eventText, To: stepAwaitingConfirmation},
+	dialog.Transition{From: stepAwaitingConfirmation, Event: eventConfirm, To: dialog.StateIdle},
+	dialog.Transition{From: stepAwaitingConfirmation, Event: eventReject, To: dialog.StateIdle},
+
+	dialog.Transition{From: stepAwaitingRecipient, Event: eventCancel, To: dialog.StateIdle},
+	dialog.Transition{From: stepAwaitingText, Event: eventCancel, To: dialog.StateIdle},
+	dialog.Transition{From: stepAwaitingConfirmation, Event: eventCancel, To: dialog.StateIdle},
+)
+
+// draftTimeout — сколько черновик ждёт получателя или текст. Брошенный на полпути
+// черновик не должен принимать следующие команды за имя получателя или текст сообщения.
+const draftTimeout = 2 * time.Minute
+
+// composeHandler ведёт диалог составления сообщения.
+// Шаг диалога и заполненные слоты хранятся в состоянии сессии.
+type composeHandler struct {
+	store   store.Store
+	phrases *i18n.Bundle
+	// confirmTimeout — сколько ждать подтверждения отправки.
+	// Если равен нулю, сообщения отправляются без подтверждения.
+	confirmTimeout time.Duration
+}
+
+func (h composeHandler) Match(req *models.Request) bool {
+	draft := req.State.Session.Compose
+	step := dialog.State(draft.Step)
+	if step != dialog.StateIdle && !draft.Expired(time.Now()) {
+		return true
+	}
+
+	// просроченный черновик перехватывает только ответ на вопрос о подтверждении,
+	// чтобы сказать, что сообщение не отправлено; остальные команды уходят своим обработчикам
+	if composeFlow.Accepts(step, eventConfirm) && (isConfirm(req) || isReject(req)) {
+		return true
+	}
+
+	return isCommand(req, parser.CommandSend)
+}
+
+func (h composeHandler) Handle(ctx context.Context, req *models.Request, resp *models.Response) error {
+	phrases := h.phrases.For(req.Meta.Locale)
+	draft := req.State.Session.Compose
+	step := dialog.State(draft.Step)
+	now := time.Now()
+
+	if step != dialog.StateIdle && draft.Expired(now) {
+		resp.SessionState.Compose = models.ComposeState{}
+		if !isCommand(req, parser.CommandSend) {
+			resp.Response.Text = phrases.Render("compose.expired", nil)
+			return nil
+		}
+
+		// пользователь начинает новое сообщение — забываем старый черновик
+		draft = models.ComposeState{}
+		step = dialog.StateIdle
+	}
+
+	// новая команда «Отправь Пете привет» вместо ответа на вопрос о подтверждении
+	// не отправляет старый черновик, а начинает новое сообщение
+	if step == stepAwaitingConfirmation && isCommand(req, parser.CommandSend) {
+		draft = models.ComposeState{}
+		step = dialog.StateIdle
+	}
+
+	var err error
+	var notFound *recipientNotFoundError
+	confirmed := false
+	switch {
+	case step != dialog.StateIdle && lexicon(req).IsCancel(req.Request.Command):
+		step, err = composeFlow.Fire(step, eventCancel)
+	case step == dialog.StateIdle:
+		confirmed = h.confirmTimeout == 0
+		if step, err = composeFlow.Fire(step, eventStart); err != nil {
+			break
+		}
+
+		cmd := parseSend(req)
+		if cmd.Recipient == "" {
+			break
+		}
+		if step, err = h.fillRecipient(ctx, step, &draft, cmd); err != nil || step != stepAwaitingText {
+			break
+		}
+		if cmd.Message != "" {
+			draft.Text = cmd.Message
+			step, err = composeFlow.Fire(step, eventText)
+		}
+	case step == stepAwaitingRecipient:
+		step, err = h.fillRecipient(ctx, step, &draft, parseSend(req))
+	case step == stepAwaitingText:
+		// ответ уходит автору сообщения, которое пользователь только что прочитал:
+		// saveMessage берёт получателя из самого сообщения, перепутать его нельзя,
+		// поэтому ответ, как и продиктованный сразу в replyHandler, не переспрашиваем
+		confirmed = h.confirmTimeout == 0 || draft.ReplyTo != 0
+		draft.Text = utterance(req)
+		step, err = composeFlow.Fire(step, eventText)
+	case step == stepAwaitingConfirmation && isConfirm(req):
+		confirmed = true
+	case step == stepAwaitingConfirmation && isReject(req):
+		step, err = composeFlow.Fire(step, eventReject)
+	}
+	// неизвестное имя — не ошибка диалога: переспросим получателя
+	if errors.As(err, &notFound) {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if step == stepAwaitingConfirmation && confirmed {
+		if step, err = composeFlow.Fire(step, eventConfirm); err != nil {
+			return err
+		}
+		if err := saveMessage(ctx, h.store, req, draft); err != nil {
+			// сообщение, на которое отвечали, удалено или чужое — такой черновик не отправить
+			if errors.Is(err, store.ErrNotFound) || errors.Is(err, store.ErrForbidden) {
+				resp.SessionState.Compose = models.ComposeState{}
+			}
+			return err
+		}
+
+		resp.SessionState.Compose = models.ComposeState{}
+		resp.Response.Text = phrases.Render("compose.sent", templates.Data{"Recipient": draft.RecipientName})
+		if draft.ReplyTo != 0 {
+			resp.Response.Text = phrases.Render("reply.sent", nil)
+		}
+		return nil
+	}
+
+	if step == dialog.StateIdle {
+		resp.SessionState.Compose = models.ComposeState{}
+		resp.Response.Text = phrases.Render("compose.cancelled", nil)
+		return nil
+	}
+
+	// черновик ждёт ответа ограниченное время: случайное «да» в конце сессии
+	// не отправит давно забытое сообщение, а «Прочитай первое» через полчаса
+	// не станет его текстом. Срок подтверждения не продлевается невнятными ответами.
+	switch {
+	case step != stepAwaitingConfirmation:
+		draft.ExpiresAt = now.Add(draftTimeout).Unix()
+	case dialog.State(draft.Step) != stepAwaitingConfirmation:
+		draft.ExpiresAt = now.Add(h.confirmTimeout).Unix()
+	}
+
+	draft.Step = string(step)
+	resp.SessionState.Compose = draft
+
+	switch step {
+	case stepAwaitingRecipient:
+		resp.Response.Text = phrases.Render("compose.ask_recipient", nil)
+		if notFound != nil {
+			resp.Response.Text = speechForError(phrases, notFound) + " " + phrases.Render("compose.ask_recipient_again", nil)
+		}
+	case stepAwaitingText:
+		resp.Response.Text = phrases.Render("compose.ask_text", nil)
+	case stepAwaitingConfirmation:
+		resp.Response.Text = phrases.Render("compose.confirm", templates.Data{
+			"Recipient": draft.RecipientName,
+			"Text":      draft.Text,
+		})
+	}
+
+	return nil
+}
+
+// fillRecipient ищет получателя по произнесённому имени и, если нашёл,
+// переводит диалог к следующему шагу.
+func (h composeHandler) fillRecipient(ctx context.Context, step dialog.State, draft *models.ComposeState, cmd parser.SendCommand) (dialog.State, error) {
+	draft.RecipientName = cmd.Recipient
+	if len(cmd.Candidates) == 0 {
+		return step, nil
+	}
+
+	recipientID, err := findRecipient(ctx, h.store, cmd.Candidates)
+	if err != nil {
+		return step, fmt.Errorf("cannot find recipient by username %q: %w", cmd.Recipient, err)
+	}
+
+	draft.Recipient = recipientID
+	return composeFlow.Fire(step, eventRecipient)
+}
+
+// isConfirm распознаёт согласие по встроенному интенту YANDEX.CONFIRM или по словам.
+func isConfirm(req *models.Request) bool {
+	return req.Request.NLU.HasIntent(models.IntentConfirm) || lexicon(req).IsConfirm(req.Request.Command)
+}
+
+// isReject распознаёт отказ по встроенному интенту YANDEX.REJECT или по словам.
+func isReject(req *models.Request) bool {
+	return req.Request.NLU.HasIntent(models.IntentReject) || lexicon(req).IsReject(req.Request.Command)
+}
+
+// parseSend разбирает команду отправки, предпочитая сущности NLU Алисы.
+func parseSend(req *models.Request) parser.SendCommand {
+	l := lexicon(req)
+	cmd, ok := l.ParseSendNLU(req.Request.OriginalUtterance, req.Request.NLU)
+	if ok {
+		return cmd
+	}
+
+	// имя ищем по нормализованной команде, а текст берём из исходной фразы,
+	// где сохранились регистр, кавычки и знаки препинания
+	cmd = l.ParseSend(req.Request.Command)
+	if req.Request.OriginalUtterance != "" {
+		cmd.Message = l.ParseSend(req.Request.OriginalUtterance).Message
+	}
+
+	return cmd
+}
+
+// findRecipient перебирает возможные начальные формы имени получателя,
+// пока не найдёт зарегистрированного пользователя.
+// Если не нашёлся никто, возвращает *recipientNotFoundError с наиболее вероятной формой имени.
+func findRecipient(ctx context.Context, s store.Store, candidates []string) (string, error) {
+	for _, username := range candidates {
+		recipientID, err := s.FindRecipient(ctx, username)
+		if !errors.Is(err, store.ErrNotFound) {
+			return recipientID, err
+		}
+	}
+
+	username := ""
+	if len(candidates) > 0 {
+		username = candidates[0]
+	}
+
+	return "", &recipientNotFoundError{username: username}
+}
+
+// utterance возвращает исходную фразу пользователя с регистром и пунктуацией.
+func utterance(req *models.Request) string {
+	if req.Request.OriginalUtterance != "" {
+		return req.Request.OriginalUtterance
+	}
+
+	return req.Request.Command
+}
+
+// saveMessage отправляет составленное сообщение от имени пользователя.
+// Номер сообщения, на которое пользователь отвечает, приходит из состояния сессии,
+// которое хранит клиент, поэтому исходное сообщение загружается заново с проверкой доступа,
+// а ответ уходит его отправителю, а не получателю из черновика.
+func saveMessage(ctx context.Context, s store.Store, req *models.Request, draft models.ComposeState) error {
+	if draft.ReplyTo != 0 {
+		parent, err := s.GetMessage(ctx, req.MailboxID(), draft.ReplyTo)
+		if err != nil {
+			return fmt.Errorf("cannot load message %d to reply to: %w", draft.ReplyTo, err)
+		}
+
+		if draft.Recipient, err = findRecipient(ctx, s, []string{parent.Sender}); err != nil {
+			return fmt.Errorf("cannot find sender %q of message %d: %w", parent.Sender, parent.ID, err)
+		}
+	}
+
+	err := s.SaveMessage(ctx, draft.Recipient, store.Message{
+		Sender:  req.MailboxID(),
+		Time:    time.Now(),
+		Payload: draft.Text,
+		ReplyTo: draft.ReplyTo,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot save message for %q: %w", draft.Recipient, err)
+	}
+
+	return nil
+}
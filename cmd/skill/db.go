@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/cache"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/logger"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/migrate"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store/cached"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store/memory"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store/pg"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store/sqlite"
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
+	_ "modernc.org/sqlite"
+)
+
+// попытки подключения к базе при старте навыка
+const (
+	connectAttempts   = 5
+	connectBackoff    = time.Second
+	connectMaxBackoff = 10 * time.Second
+)
+
+// backend — реализация хранилища на базе данных.
+type backend struct {
+	driver      string
+	dsn         string
+	newStore    func(conn *sql.DB) store.Store
+	newMigrator func(conn *sql.DB) (*migrate.Migrator, error)
+	// singleWriter означает, что база не допускает параллельной записи
+	// и пулу нужно одно соединение
+	singleWriter bool
+}
+
+// parseDatabaseURI выбирает хранилище по схеме адреса базы:
+// sqlite://path.db — SQLite, postgres:// и строки подключения вида
+// «host=... dbname=...» — Postgres.
+func parseDatabaseURI(uri string) (backend, error) {
+	scheme, rest, hasScheme := strings.Cut(uri, "://")
+	switch {
+	case scheme == "sqlite":
+		if rest == "" {
+			return backend{}, errors.New("sqlite database path is empty")
+		}
+		return backend{
+			driver: "sqlite",
+			dsn:    sqlite.DSN(rest),
+			newStore: func(conn *sql.DB) store.Store {
+				return sqlite.NewStore(conn)
+			},
+			newMigrator:  sqlite.NewMigrator,
+			singleWriter: true,
+		}, nil
+	case scheme == "postgres" || scheme == "postgresql" || !hasScheme:
+		return backend{
+			driver: "pgx",
+			dsn:    uri,
+			newStore: func(conn *sql.DB) store.Store {
+				return pg.NewStore(conn)
+			},
+			newMigrator: pg.NewMigrator,
+		}, nil
+	default:
+		return backend{}, fmt.Errorf("unsupported database scheme %q", scheme)
+	}
+}
+
+// openStore выбирает хранилище по флагам. Без адреса базы навык хранит
+// сообщения в памяти процесса, это удобно для локального запуска.
+// Возвращает также проверку доступности хранилища для /readyz и функцию закрытия.
+func openStore(ctx context.Context) (store.Store, pinger, func() error, error) {
+	if flagDatabaseURI == "" {
+		logger.Log.Warn("Database URI is not set, messages are kept in memory")
+		s := memory.NewStore()
+		return s, s, func() error { return nil }, nil
+	}
+
+	b, err := parseDatabaseURI(flagDatabaseURI)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	conn, err := openDB(ctx, b)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// схема обновляется при каждом запуске, чтобы новая версия навыка
+	// не начала работать со старыми таблицами
+	migrator, err := b.newMigrator(conn)
+	if err == nil {
+		_, err = migrator.Up(ctx)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, nil, nil, err
+	}
+
+	return b.newStore(conn), conn, conn.Close, nil
+}
+
+// withCache добавляет к хранилищу кэш списков сообщений, если он включён флагами.
+// Кэш в Redis общий для всех экземпляров навыка, в памяти — свой у каждого.
+func withCache(s store.Store) (store.Store, func() error) {
+	if flagCacheTTL <= 0 {
+		return s, func() error { return nil }
+	}
+
+	if flagRedisAddr != "" {
+		c := cache.NewRedis(flagRedisAddr, time.Second)
+		return cached.NewStore(s, c, flagCacheTTL), c.Close
+	}
+
+	return cached.NewStore(s, cache.NewLRU(flagCacheSize), flagCacheTTL), func() error { return nil }
+}
+
+// openDB открывает пул соединений с настройками из флагов и ждёт,
+// пока база станет доступна: при старте в оркестраторе навык и база
+// часто поднимаются одновременно.
+func openDB(ctx context.Context, b backend) (*sql.DB, error) {
+	conn, err := sql.Open(b.driver, b.dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	conn.SetMaxOpenConns(flagMaxOpenConns)
+	conn.SetMaxIdleConns(flagMaxIdleConns)
+	conn.SetConnMaxLifetime(flagConnMaxLifetime)
+	conn.SetConnMaxIdleTime(flagConnMaxIdleTime)
+	if b.singleWriter {
+		conn.SetMaxOpenConns(1)
+	}
+
+	if err := pingWithRetry(ctx, conn, connectAttempts, connectBackoff); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// pingWithRetry проверяет соединение, удваивая паузу после каждой неудачной попытки.
+func pingWithRetry(ctx context.Context, p pinger, attempts int, backoff time.Duration) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if err = p.PingContext(ctx); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+
+		logger.Log.Warn("Database is unavailable, retrying",
+			zap.Int("attempt", attempt), zap.Duration("backoff", backoff), zap.Error(err))
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > connectMaxBackoff {
+			backoff = connectMaxBackoff
+		}
+	}
+
+	return fmt.Errorf("database is unavailable after %d attempts: %w", attempts, err)
+}
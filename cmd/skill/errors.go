@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/templates"
+)
+
+// errBadRequest — ошибка обработчика, вызванная некорректным запросом.
+var errBadRequest = errors.New("bad request")
+
+// errNoUnread — пользователь назвал номер непрочитанного сообщения, а непрочитанных нет.
+var errNoUnread = errors.New("no unread messages")
+
+// recipientNotFoundError — пользователя с произнесённым именем нет среди зарегистрированных.
+type recipientNotFoundError struct {
+	username string
+}
+
+func (e *recipientNotFoundError) Error() string {
+	return fmt.Sprintf("recipient %q not found", e.username)
+}
+
+func (e *recipientNotFoundError) Unwrap() error {
+	return store.ErrNotFound
+}
+
+// speechForError возвращает фразу, которой навык сообщает пользователю об ошибке.
+// Алиса не показывает пользователю HTTP-коды, а на ответ 500 говорит «навык не отвечает»,
+// поэтому любая ошибка обработчика превращается в обычный ответ.
+func speechForError(phrases *templates.Set, err error) string {
+	var notFound *recipientNotFoundError
+
+	switch {
+	case errors.As(err, &notFound):
+		return phrases.Render("error.recipient_not_found", templates.Data{"Username": notFound.username})
+	case errors.Is(err, store.ErrNotFound):
+		return phrases.Render("error.message_not_found", nil)
+	case errors.Is(err, store.ErrForbidden):
+		return phrases.Render("error.forbidden", nil)
+	case errors.Is(err, store.ErrConflict):
+		return phrases.Render("error.conflict", nil)
+	case errors.Is(err, errNoUnread):
+		return phrases.Render("greeting.no_unread", nil)
+	case errors.Is(err, errBadRequest):
+		return phrases.Render("error.bad_request", nil)
+	default:
+		return phrases.Render("error.internal", nil)
+	}
+}
@@ -1,20 +1,59 @@
 package main
 
 import (
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"strconv"
+	"time"
 )
 
 var flagRunAddr string
 var flagLogLevel string
 var flagDatabaseURI string
+var flagConfirmTimeout time.Duration
 
-func parseFlags() {
+// настройки пула соединений с базой
+var flagMaxOpenConns int
+var flagMaxIdleConns int
+var flagConnMaxLifetime time.Duration
+var flagConnMaxIdleTime time.Duration
+
+// настройки кэша списков сообщений; кэш выключен по умолчанию: кэш в памяти
+// у каждой реплики свой и может отдавать устаревшие списки
+var flagCacheTTL time.Duration
+var flagCacheSize int
+var flagRedisAddr string
+
+// доставка событий о новых сообщениях
+var flagOutboxInterval time.Duration
+var flagOutboxWebhook string
+
+// parseFlags разбирает флаги командной строки и переменные окружения,
+// которые важнее флагов. Неверное значение переменной — ошибка:
+// лучше не запуститься, чем молча работать с настройкой по умолчанию.
+func parseFlags() error {
 	flag.StringVar(&flagRunAddr, "a", ":8080", "address and port")
 	flag.StringVar(&flagLogLevel, "l", "debug", "log level")
-	flag.StringVar(&flagDatabaseURI, "d", "", "database URI")
+	flag.StringVar(&flagDatabaseURI, "d", "", "database URI: postgres://... or sqlite://path.db, empty keeps messages in memory")
+	flag.DurationVar(&flagConfirmTimeout, "c", 2*time.Minute, "message confirmation timeout, 0 disables confirmation")
+	flag.IntVar(&flagMaxOpenConns, "max-open-conns", 10, "max open database connections, 0 means unlimited")
+	flag.IntVar(&flagMaxIdleConns, "max-idle-conns", 5, "max idle database connections")
+	flag.DurationVar(&flagConnMaxLifetime, "conn-max-lifetime", 30*time.Minute, "max database connection lifetime, 0 means unlimited")
+	flag.DurationVar(&flagConnMaxIdleTime, "conn-max-idle-time", 5*time.Minute, "max database connection idle time, 0 means unlimited")
+	flag.DurationVar(&flagCacheTTL, "cache-ttl", 0, "message list cache ttl, 0 disables cache; without -redis every replica caches on its own")
+	flag.IntVar(&flagCacheSize, "cache-size", 10000, "max message lists in in-process cache")
+	flag.StringVar(&flagRedisAddr, "redis", "", "redis address host:port, empty keeps cache in process")
+	flag.DurationVar(&flagOutboxInterval, "outbox-interval", time.Second, "outbox polling interval, 0 disables event delivery")
+	flag.StringVar(&flagOutboxWebhook, "outbox-webhook", "", "URL to POST new message events to")
 	flag.Parse()
 
+	return parseEnv()
+}
+
+// parseEnv переопределяет флаги переменными окружения.
+func parseEnv() error {
 	if envRunAddr := os.Getenv("RUN_ADDR"); envRunAddr != "" {
 		flagRunAddr = envRunAddr
 	}
@@ -26,4 +65,55 @@ func parseFlags() {
 	if envDatabaseUri := os.Getenv("DATABASE_URI"); envDatabaseUri != "" {
 		flagDatabaseURI = envDatabaseUri
 	}
+
+	if envRedisAddr := os.Getenv("REDIS_ADDR"); envRedisAddr != "" {
+		flagRedisAddr = envRedisAddr
+	}
+
+	if envOutboxWebhook := os.Getenv("OUTBOX_WEBHOOK_URL"); envOutboxWebhook != "" {
+		flagOutboxWebhook = envOutboxWebhook
+	}
+
+	return errors.Join(
+		durationEnv("CONFIRM_TIMEOUT", &flagConfirmTimeout),
+		intEnv("DB_MAX_OPEN_CONNS", &flagMaxOpenConns),
+		intEnv("DB_MAX_IDLE_CONNS", &flagMaxIdleConns),
+		durationEnv("DB_CONN_MAX_LIFETIME", &flagConnMaxLifetime),
+		durationEnv("DB_CONN_MAX_IDLE_TIME", &flagConnMaxIdleTime),
+		durationEnv("CACHE_TTL", &flagCacheTTL),
+		intEnv("CACHE_SIZE", &flagCacheSize),
+		durationEnv("OUTBOX_INTERVAL", &flagOutboxInterval),
+	)
+}
+
+// durationEnv записывает в dst длительность из переменной окружения name, если она задана.
+func durationEnv(name string, dst *time.Duration) error {
+	env := os.Getenv(name)
+	if env == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(env)
+	if err != nil {
+		return fmt.Errorf("cannot parse %s=%q as duration: %w", name, env, err)
+	}
+
+	*dst = d
+	return nil
+}
+
+// intEnv записывает в dst число из переменной окружения name, если она задана.
+func intEnv(name string, dst *int) error {
+	env := os.Getenv(name)
+	if env == "" {
+		return nil
+	}
+
+	n, err := strconv.Atoi(env)
+	if err != nil {
+		return fmt.Errorf("cannot parse %s=%q as integer: %w", name, env, err)
+	}
+
+	*dst = n
+	return nil
 }
@@ -0,0 +1,472 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/i18n"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/models"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/parser"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/router"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/templates"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/tts"
+)
+
+// readHandler зачитывает сообщение по номеру: «Прочитай ...».
+type readHandler struct {
+	store   store.Store
+	phrases *i18n.Bundle
+}
+
+func (h readHandler) Match(req *models.Request) bool {
+	return isCommand(req, parser.CommandRead) || router.HasAction(req, models.ActionRead)
+}
+
+func (h readHandler) Handle(ctx context.Context, req *models.Request, resp *models.Response) error {
+	// элементы карточки ссылаются на конкретное сообщение
+	if p, ok := req.Request.ButtonPayload(); ok && p.MessageID != 0 {
+		return h.read(ctx, req, p.MessageID, resp)
+	}
+
+	messageIndex, ok := parser.ParseReadNLU(req.Request.NLU)
+	if !ok {
+		messageIndex = lexicon(req).ParseRead(req.Request.Command)
+	}
+	if p, ok := req.Request.ButtonPayload(); ok {
+		messageIndex = p.Index
+	}
+
+	messageID, err := numberedMessage(ctx, h.store, req, messageIndex)
+	if err != nil {
+		return err
+	}
+
+	return h.read(ctx, req, messageID, resp)
+}
+
+// numberedMessage находит сообщение по номеру, который назвал пользователь,
+// или LastIndex для последнего. «Прочитай второе» и «Удали второе» нумеруют
+// один и тот же список. Пока пользователь слушает входящие, это сообщения,
+// которые навык назвал на странице, иначе — непрочитанные сообщения
+// от старых к новым, как их пересчитывает приветствие.
+// Если сообщения с таким номером нет, возвращает store.ErrNotFound.
+func numberedMessage(ctx context.Context, s store.Store, req *models.Request, index int) (int64, error) {
+	if inbox := req.State.Session.Inbox; len(inbox.IDs) > 0 {
+		// на второй странице первым названо четвёртое сообщение
+		i := index - (inbox.Page-1)*inboxPageSize
+		if index == parser.LastIndex {
+			i = len(inbox.IDs) - 1
+		}
+		if i < 0 || i >= len(inbox.IDs) {
+			return 0, fmt.Errorf("no message number %d on inbox page %d: %w", index+1, inbox.Page, store.ErrNotFound)
+		}
+
+		return inbox.IDs[i], nil
+	}
+
+	messages, err := s.ListUnread(ctx, req.MailboxID())
+	if err != nil {
+		return 0, fmt.Errorf("cannot load unread messages for user: %w", err)
+	}
+	if len(messages) == 0 {
+		return 0, errNoUnread
+	}
+
+	if index == parser.LastIndex {
+		index = len(messages) - 1
+	}
+	if index < 0 || index >= len(messages) {
+		return 0, fmt.Errorf("no unread message number %d: %w", index+1, store.ErrNotFound)
+	}
+
+	return messages[index].ID, nil
+}
+
+// read зачитывает сообщение и отмечает его прочитанным.
+func (h readHandler) read(ctx context.Context, req *models.Request, messageID int64, resp *models.Response) error {
+	phrases := h.phrases.For(req.Meta.Locale)
+	userID := req.MailboxID()
+	message, err := h.store.GetMessage(ctx, userID, messageID)
+	if err != nil {
+		return fmt.Errorf("cannot load message %d: %w", messageID, err)
+	}
+
+	if err := h.store.MarkRead(ctx, userID, messageID); err != nil {
+		return fmt.Errorf("cannot mark message %d as read: %w", messageID, err)
+	}
+
+	// запомним сообщение, чтобы на него можно было ответить
+	resp.SessionState.LastRead = models.LastReadState{
+		MessageID: message.ID,
+		Sender:    message.Sender,
+	}
+
+	// передадим текст сообщения в ответе
+	resp.Response.Text = phrases.Render("read.message", templates.Data{
+		"Sender": message.Sender,
+		"SentAt": sentAt(phrases, message.Time, time.Now(), userLocation(req)),
+		"Text":   message.Payload,
+	})
+	resp.Response.Buttons = []models.Button{
+		{
+			Title:   phrases.Render("button.reply", nil),
+			Payload: &models.ButtonPayload{Action: models.ActionReply, MessageID: messageID},
+			Hide:    true,
+		},
+		{
+			Title:   phrases.Render("button.delete", nil),
+			Payload: &models.ButtonPayload{Action: models.ActionDelete, MessageID: messageID},
+			Hide:    true,
+		},
+	}
+	return nil
+}
+
+// replyHandler отвечает отправителю последнего прочитанного сообщения: «Ответь: буду в семь».
+type replyHandler struct {
+	store   store.Store
+	phrases *i18n.Bundle
+}
+
+func (h replyHandler) Match(req *models.Request) bool {
+	return isCommand(req, parser.CommandReply) || router.HasAction(req, models.ActionReply)
+}
+
+func (h replyHandler) Handle(ctx context.Context, req *models.Request, resp *models.Response) error {
+	phrases := h.phrases.For(req.Meta.Locale)
+	lastRead := req.State.Session.LastRead
+
+	// кнопка «Ответить» ссылается на конкретное сообщение
+	if p, ok := req.Request.ButtonPayload(); ok && p.MessageID != 0 && p.MessageID != lastRead.MessageID {
+		message, err := h.store.GetMessage(ctx, req.MailboxID(), p.MessageID)
+		if err != nil {
+			return fmt.Errorf("cannot load message %d: %w", p.MessageID, err)
+		}
+		lastRead = models.LastReadState{MessageID: message.ID, Sender: message.Sender}
+	}
+
+	if lastRead.MessageID == 0 {
+		resp.Response.Text = phrases.Render("reply.nothing_read", nil)
+		return nil
+	}
+
+	recipientID, err := findRecipient(ctx, h.store, []string{lastRead.Sender})
+	if err != nil {
+		return fmt.Errorf("cannot find sender %q of message %d: %w", lastRead.Sender, lastRead.MessageID, err)
+	}
+
+	draft := models.ComposeState{
+		Recipient:     recipientID,
+		RecipientName: lastRead.Sender,
+		Text:          lexicon(req).ParseReply(utterance(req)),
+		ReplyTo:       lastRead.MessageID,
+	}
+
+	// текст не продиктовали — продолжим диалогом составления сообщения
+	if draft.Text == "" {
+		draft.Step = string(stepAwaitingText)
+		draft.ExpiresAt = time.Now().Add(draftTimeout).Unix()
+		resp.SessionState.Compose = draft
+		resp.Response.Text = phrases.Render("reply.ask_text", nil)
+		return nil
+	}
+
+	// получатель известен точно, поэтому ответ отправляем без подтверждения
+	if err := saveMessage(ctx, h.store, req, draft); err != nil {
+		return err
+	}
+
+	resp.Response.Text = phrases.Render("reply.sent", nil)
+	return nil
+}
+
+// threadHandler зачитывает переписку по последнему прочитанному сообщению: «Прочитай переписку».
+type threadHandler struct {
+	store   store.Store
+	phrases *i18n.Bundle
+}
+
+func (h threadHandler) Match(req *models.Request) bool {
+	return isCommand(req, parser.CommandThread)
+}
+
+func (h threadHandler) Handle(ctx context.Context, req *models.Request, resp *models.Response) error {
+	phrases := h.phrases.For(req.Meta.Locale)
+	lastRead := req.State.Session.LastRead
+	if lastRead.MessageID == 0 {
+		resp.Response.Text = phrases.Render("thread.nothing_read", nil)
+		return nil
+	}
+
+	messages, err := h.store.ListThread(ctx, req.MailboxID(), lastRead.MessageID)
+	if err != nil {
+		return fmt.Errorf("cannot load thread of message %d: %w", lastRead.MessageID, err)
+	}
+
+	lines := make([]string, 0, len(messages))
+	for _, m := range messages {
+		lines = append(lines, phrases.Render("thread.line", templates.Data{"Sender": m.Sender, "Text": m.Payload}))
+	}
+
+	resp.Response.Text = strings.Join(lines, "\n")
+	return nil
+}
+
+// deleteHandler удаляет сообщения: «Удали второе сообщение»,
+// «Удали все прочитанные», «Очисти входящие». Перед удалением всех
+// сообщений навык переспрашивает.
+type deleteHandler struct {
+	store   store.Store
+	phrases *i18n.Bundle
+}
+
+func (h deleteHandler) Match(req *models.Request) bool {
+	if req.State.Session.ClearInbox && (isConfirm(req) || isReject(req)) {
+		return true
+	}
+
+	return isCommand(req, parser.CommandDelete) ||
+		isCommand(req, parser.CommandClear) ||
+		router.HasAction(req, models.ActionDelete)
+}
+
+func (h deleteHandler) Handle(ctx context.Context, req *models.Request, resp *models.Response) error {
+	phrases := h.phrases.For(req.Meta.Locale)
+	userID := req.MailboxID()
+
+	if p, ok := req.Request.ButtonPayload(); ok && p.MessageID != 0 {
+		return h.deleteOne(ctx, req, p.MessageID, resp)
+	}
+
+	// ответ на вопрос «Удалить все сообщения?»
+	if req.State.Session.ClearInbox {
+		if isReject(req) {
+			resp.Response.Text = phrases.Render("delete.all_cancelled", nil)
+			return nil
+		}
+
+		n, err := h.store.DeleteAll(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("cannot delete messages: %w", err)
+		}
+
+		resp.Response.Text = phrases.Render("delete.all", templates.Data{"Count": int(n)})
+		return nil
+	}
+
+	scope, messageIndex := lexicon(req).ParseDelete(req.Request.Command)
+	switch scope {
+	case parser.DeleteRead:
+		n, err := h.store.DeleteRead(ctx, userID)
+		if err != nil {
+			return fmt.Errorf("cannot delete read messages: %w", err)
+		}
+
+		resp.Response.Text = phrases.Render("delete.read", templates.Data{"Count": int(n)})
+		return nil
+	case parser.DeleteAll:
+		// удаление всех сообщений не отменить, поэтому сначала переспросим
+		resp.SessionState.ClearInbox = true
+		resp.Response.Text = phrases.Render("delete.confirm_all", nil)
+		return nil
+	}
+
+	messageID, err := numberedMessage(ctx, h.store, req, messageIndex)
+	if err != nil {
+		return err
+	}
+
+	return h.deleteOne(ctx, req, messageID, resp)
+}
+
+func (h deleteHandler) deleteOne(ctx context.Context, req *models.Request, messageID int64, resp *models.Response) error {
+	phrases := h.phrases.For(req.Meta.Locale)
+	err := h.store.DeleteMessage(ctx, req.MailboxID(), messageID)
+	if err != nil {
+		return fmt.Errorf("cannot delete message %d: %w", messageID, err)
+	}
+
+	resp.Response.Text = phrases.Render("delete.one", nil)
+	return nil
+}
+
+// registerHandler регистрирует пользователя под именем: «Зарегистрируй ...».
+type registerHandler struct {
+	store   store.Store
+	phrases *i18n.Bundle
+}
+
+func (h registerHandler) Match(req *models.Request) bool {
+	return isCommand(req, parser.CommandRegister)
+}
+
+func (h registerHandler) Handle(ctx context.Context, req *models.Request, resp *models.Response) error {
+	phrases := h.phrases.For(req.Meta.Locale)
+	username := lexicon(req).ParseRegister(req.Request.Command)
+	err := h.store.RegisterUser(ctx, req.MailboxID(), username)
+	if errors.Is(err, store.ErrConflict) {
+		resp.Response.Text = phrases.Render("error.conflict", nil)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("cannot register user: %w", err)
+	}
+
+	// запомним имя в состоянии навыка, чтобы обращаться к пользователю по имени
+	resp.UserStateUpdate = &models.UserState{Username: username}
+	resp.ApplicationState.Username = username
+
+	resp.Response.Text = phrases.Render("register.done", templates.Data{"Username": username})
+	return nil
+}
+
+// lexicon возвращает слова, которыми пользователь отдаёт команды на своём языке.
+func lexicon(req *models.Request) *parser.Lexicon {
+	return parser.For(req.Meta.Locale)
+}
+
+// isCommand проверяет, что пользователь произнёс команду c на своём языке.
+func isCommand(req *models.Request, c parser.Command) bool {
+	return lexicon(req).Is(req.Request.Command, c)
+}
+
+// userLocation возвращает часовой пояс пользователя из запроса или UTC, если он неизвестен.
+func userLocation(req *models.Request) *time.Location {
+	tz, err := time.LoadLocation(req.UserTimezone())
+	if err != nil {
+		return time.UTC
+	}
+
+	return tz
+}
+
+// greetingHandler сообщает количество непрочитанных сообщений.
+// Используется для всех запросов, не подошедших другим обработчикам.
+type greetingHandler struct {
+	store   store.Store
+	phrases *i18n.Bundle
+}
+
+func (h greetingHandler) Match(req *models.Request) bool {
+	return true
+}
+
+func (h greetingHandler) Handle(ctx context.Context, req *models.Request, resp *models.Response) error {
+	phrases := h.phrases.For(req.Meta.Locale)
+	messages, err := h.store.ListUnread(ctx, req.MailboxID())
+	if err != nil {
+		return fmt.Errorf("cannot load unread messages for user: %w", err)
+	}
+
+	// обработаем часовой пояс запроса
+	tz, err := time.LoadLocation(req.UserTimezone())
+	if err != nil {
+		// без часового пояса не назвать точное время в начале сессии
+		if req.Session.New {
+			return fmt.Errorf("%w: cannot parse timezone %q: %v", errBadRequest, req.UserTimezone(), err)
+		}
+		tz = time.UTC
+	}
+
+	// приветствие пересчитывает непрочитанные: номера снова относятся к ним
+	resp.SessionState.Inbox = models.InboxState{}
+
+	speech := tts.New()
+	text := phrases.Render("greeting.no_unread", nil)
+	if len(messages) > 0 {
+		text = phrases.Render("greeting.unread", templates.Data{"Count": len(messages)})
+		speech.Sound(tts.SoundBell)
+
+		// кнопки относятся к первому непрочитанному сообщению
+		first := messages[0].ID
+		resp.Response.Buttons = []models.Button{
+			{
+				Title:   phrases.Render("button.read_first", nil),
+				Payload: &models.ButtonPayload{Action: models.ActionRead, MessageID: first},
+				Hide:    true,
+			},
+			{
+				Title:   phrases.Render("button.reply", nil),
+				Payload: &models.ButtonPayload{Action: models.ActionReply, MessageID: first},
+				Hide:    true,
+			},
+			{
+				Title:   phrases.Render("button.delete", nil),
+				Payload: &models.ButtonPayload{Action: models.ActionDelete, MessageID: first},
+				Hide:    true,
+			},
+		}
+
+		// на устройствах с экраном дополнительно покажем список сообщений,
+		// колонкам карточка не уйдёт: её уберёт webhook
+		resp.Response.Card = messagesCard(phrases, messages, tz)
+	}
+
+	// первый запрос новой сессии
+	if req.Session.New {
+		// получим текущее время в часовом поясе пользователя
+		now := time.Now().In(tz)
+		hour, minute, _ := now.Clock()
+
+		// формируем новый текст приветствия, в речи отделим время паузой
+		clock := phrases.Render("greeting.clock", templates.Data{"Hour": hour, "Minute": minute})
+		if username := req.Username(); username != "" {
+			clock = phrases.Render("greeting.hello", templates.Data{"Username": username}) + " " + clock
+		}
+		speech.Text(clock).Pause(300 * time.Millisecond)
+		resp.Response.TTS = speech.Text(text).String()
+		resp.Response.Text = fmt.Sprintf("%s %s", clock, text)
+		return nil
+	}
+
+	resp.Response.Text = text
+	resp.Response.TTS = speech.Text(text).String()
+	return nil
+}
+
+// messagesCard формирует карточку со списком сообщений.
+// Нажатие на элемент зачитывает соответствующее сообщение: элементы ссылаются
+// на идентификатор, а не на номер, потому что номера сдвигаются по мере прочтения.
+func messagesCard(phrases *templates.Set, messages []store.Message, tz *time.Location) *models.Card {
+	var items []models.CardItem
+	for _, m := range messages {
+		if len(items) == models.MaxListItems {
+			break
+		}
+
+		items = append(items, models.CardItem{
+			Title:       m.Sender,
+			Description: m.Time.In(tz).Format("02.01 15:04"),
+			Button: &models.CardButton{
+				Payload: &models.ButtonPayload{Action: models.ActionRead, MessageID: m.ID},
+			},
+		})
+	}
+
+	card := models.NewItemsList(phrases.Render("card.new_messages", nil), items)
+	if rest := len(messages) - len(items); rest > 0 {
+		card.Footer = &models.CardFooter{Text: phrases.Render("card.more", templates.Data{"Count": rest})}
+	}
+
+	return card
+}
+
+// exitHandler завершает сессию по просьбе пользователя.
+type exitHandler struct {
+	phrases *i18n.Bundle
+}
+
+func (h exitHandler) Match(req *models.Request) bool {
+	return isCommand(req, parser.CommandExit)
+}
+
+func (h exitHandler) Handle(_ context.Context, req *models.Request, resp *models.Response) error {
+	phrases := h.phrases.For(req.Meta.Locale)
+	resp.Response.Text = phrases.Render("exit.goodbye", nil)
+	resp.Response.EndSession = true
+	return nil
+}
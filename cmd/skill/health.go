@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/logger"
+	"go.uber.org/zap"
+)
+
+// readyTimeout ограничивает проверку базы в /readyz,
+// чтобы оркестратор не ждал зависшее соединение.
+const readyTimeout = 2 * time.Second
+
+// pinger проверяет доступность зависимости навыка, например *sql.DB.
+type pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// healthz отвечает, что процесс навыка жив. Базу не проверяет:
+// её недоступность не повод перезапускать навык.
+func healthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ok"))
+}
+
+// readyz отвечает, готов ли навык принимать запросы, то есть доступна ли база.
+func readyz(db pinger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), readyTimeout)
+		defer cancel()
+
+		if err := db.PingContext(ctx); err != nil {
+			logger.Log.Debug("database is not ready", zap.Error(err))
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("database is unavailable"))
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("ok"))
+	}
+}
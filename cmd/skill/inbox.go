@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/i18n"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/models"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/parser"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/router"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/templates"
+)
+
+// inboxPageSize — сколько сообщений зачитывать за раз: больше на слух не запомнить.
+const inboxPageSize = 3
+
+// inboxHandler листает входящие по три сообщения, от старых к новым, как
+// и остальные списки навыка: «Покажи сообщения» → «Дальше» → «Назад».
+// Номер страницы и сообщения на ней хранятся в состоянии сессии.
+type inboxHandler struct {
+	store   store.Store
+	phrases *i18n.Bundle
+}
+
+func (h inboxHandler) Match(req *models.Request) bool {
+	return isCommand(req, parser.CommandInbox) || h.isNext(req) || h.isBack(req)
+}
+
+// isNext и isBack распознают листание голосом и по надписи кнопки:
+// кнопка без payload присылает свою надпись как команду.
+func (h inboxHandler) isNext(req *models.Request) bool {
+	return isCommand(req, parser.CommandNext) ||
+		router.HasCommandPrefix(req, h.phrases.For(req.Meta.Locale).Render("button.next", nil))
+}
+
+func (h inboxHandler) isBack(req *models.Request) bool {
+	return isCommand(req, parser.CommandBack) ||
+		router.HasCommandPrefix(req, h.phrases.For(req.Meta.Locale).Render("button.back", nil))
+}
+
+func (h inboxHandler) Handle(ctx context.Context, req *models.Request, resp *models.Response) error {
+	phrases := h.phrases.For(req.Meta.Locale)
+	inbox := req.State.Session.Inbox
+
+	// страницы листаются курсором от сообщений текущей страницы, а не смещением:
+	// удалённое или новое сообщение не сдвинет следующую страницу
+	page := 1
+	opts := store.ListOptions{Limit: inboxPageSize + 1}
+	switch {
+	case h.isNext(req) && len(inbox.IDs) > 0:
+		page = inbox.Page + 1
+		opts.After = inbox.IDs[len(inbox.IDs)-1]
+	case h.isBack(req):
+		if inbox.Page <= 1 || len(inbox.IDs) == 0 {
+			resp.SessionState.Inbox.Page = 1
+			resp.Response.Text = phrases.Render("inbox.start", nil)
+			return nil
+		}
+		page = inbox.Page - 1
+		opts = store.ListOptions{Order: store.OrderDesc, After: inbox.IDs[0], Limit: inboxPageSize}
+	}
+
+	messages, err := h.store.ListMessages(ctx, req.MailboxID(), opts)
+	if err != nil {
+		return fmt.Errorf("cannot load messages page %d: %w", page, err)
+	}
+
+	// предыдущая страница выбрана с конца, а за ней точно есть текущая
+	hasNext := true
+	if opts.Order == store.OrderDesc {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	} else {
+		// берём на одно сообщение больше, чтобы знать, есть ли следующая страница
+		hasNext = len(messages) > inboxPageSize
+		if hasNext {
+			messages = messages[:inboxPageSize]
+		}
+	}
+
+	if len(messages) == 0 {
+		if page == 1 {
+			resp.SessionState.Inbox = models.InboxState{}
+			resp.Response.Text = phrases.Render("inbox.empty", nil)
+			return nil
+		}
+
+		resp.Response.Text = phrases.Render("inbox.no_more", nil)
+		return nil
+	}
+
+	now, tz := time.Now(), userLocation(req)
+	first := (page-1)*inboxPageSize + 1
+	ids := make([]int64, 0, len(messages))
+	lines := []string{phrases.Render("inbox.header", templates.Data{"From": first, "To": first + len(messages) - 1})}
+	for i, m := range messages {
+		ids = append(ids, m.ID)
+		lines = append(lines, phrases.Render("inbox.item", templates.Data{
+			"Number": first + i,
+			"Sender": m.Sender,
+			"SentAt": sentAt(phrases, m.Time, now, tz),
+		}))
+		resp.Response.Buttons = append(resp.Response.Buttons, models.Button{
+			Title:   fmt.Sprintf("%d. %s", first+i, m.Sender),
+			Payload: &models.ButtonPayload{Action: models.ActionRead, MessageID: m.ID},
+			Hide:    true,
+		})
+	}
+
+	if hasNext {
+		lines = append(lines, phrases.Render("inbox.more", nil))
+		resp.Response.Buttons = append(resp.Response.Buttons, models.Button{Title: phrases.Render("button.next", nil), Hide: true})
+	} else {
+		lines = append(lines, phrases.Render("inbox.end", nil))
+	}
+	if page > 1 {
+		resp.Response.Buttons = append(resp.Response.Buttons, models.Button{Title: phrases.Render("button.back", nil), Hide: true})
+	}
+
+	// номера, которые услышал пользователь, запоминаем вместе с сообщениями
+	resp.SessionState.Inbox = models.InboxState{Page: page, IDs: ids}
+	resp.Response.Text = strings.Join(lines, "\n")
+	return nil
+}
@@ -2,15 +2,20 @@ package main
 
 import (
 	"bitbucket.org/sotavant/yandex-alice-skill/internal/logger"
-	"bitbucket.org/sotavant/yandex-alice-skill/internal/store/pg"
-	"database/sql"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/outbox"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"context"
+	"flag"
 	"go.uber.org/zap"
 	"net/http"
 	"strings"
+	"time"
 )
 
 func main() {
-	parseFlags()
+	if err := parseFlags(); err != nil {
+		panic(err)
+	}
 	if err := run(); err != nil {
 		panic(err)
 	}
@@ -60,19 +65,61 @@ func gzipMiddleware(h http.HandlerFunc) http.HandlerFunc {
 		h.ServeHTTP(ow, r)
 	}
 }
+
+// newDispatcher настраивает доставку событий: в лог всегда,
+// во внешнюю систему — если задан адрес вебхука.
+func newDispatcher(source store.Outbox) *outbox.Dispatcher {
+	sinks := []outbox.Sink{outbox.LogSink{Logger: logger.Log}}
+	if flagOutboxWebhook != "" {
+		sinks = append(sinks, outbox.WebhookSink{
+			URL:    flagOutboxWebhook,
+			Client: &http.Client{Timeout: 5 * time.Second},
+		})
+	}
+
+	return outbox.NewDispatcher(source, flagOutboxInterval, sinks...)
+}
+
 func run() error {
 	if err := logger.Initialize(flagLogLevel); err != nil {
 		return err
 	}
 
-	conn, err := sql.Open("pgx", flagDatabaseURI)
+	ctx := context.Background()
+	if flag.Arg(0) == "migrate" {
+		return runMigrate(ctx, flag.Args()[1:])
+	}
+
+	s, db, closeStore, err := openStore(ctx)
 	if err != nil {
 		return err
 	}
+	defer closeStore()
+
+	// события читаются из самого хранилища, а не из кэша поверх него
+	if source, ok := s.(store.Outbox); ok && flagOutboxInterval > 0 {
+		dispatchCtx, stopDispatch := context.WithCancel(ctx)
+		defer stopDispatch()
+
+		go newDispatcher(source).Run(dispatchCtx)
+	}
 
-	appInstance := newApp(pg.NewStore(conn))
+	s, closeCache := withCache(s)
+	defer closeCache()
+
+	opts := []option{withRandomPhrases()}
+	if flagConfirmTimeout > 0 {
+		opts = append(opts, withSendConfirmation(flagConfirmTimeout))
+	}
+
+	appInstance := newApp(s, opts...)
 
 	logger.Log.Info("Running server", zap.String("address", flagRunAddr))
 
-	return http.ListenAndServe(flagRunAddr, logger.RequestLogger(gzipMiddleware(appInstance.webhook)))
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", healthz)
+	mux.HandleFunc("/readyz", readyz(db))
+	mux.Handle("/", logger.RequestLogger(gzipMiddleware(appInstance.webhook)))
+
+	return http.ListenAndServe(flagRunAddr, mux)
 }
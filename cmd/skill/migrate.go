@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/logger"
+	"go.uber.org/zap"
+)
+
+// runMigrate выполняет подкоманду migrate:
+//
+//	skill migrate [up]     — применить все новые миграции
+//	skill migrate down [N] — откатить N последних миграций, по умолчанию одну
+//	skill migrate version  — показать текущую версию схемы
+func runMigrate(ctx context.Context, args []string) error {
+	if flagDatabaseURI == "" {
+		return errors.New("database URI is not set")
+	}
+
+	b, err := parseDatabaseURI(flagDatabaseURI)
+	if err != nil {
+		return err
+	}
+
+	conn, err := openDB(ctx, b)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	migrator, err := b.newMigrator(conn)
+	if err != nil {
+		return err
+	}
+
+	cmd := "up"
+	if len(args) > 0 {
+		cmd = args[0]
+	}
+
+	switch cmd {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return err
+		}
+		logger.Log.Info("Migrations applied", zap.Int64s("versions", applied))
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("bad number of steps %q", args[1])
+			}
+		}
+
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return err
+		}
+		logger.Log.Info("Migrations reverted", zap.Int64s("versions", reverted))
+	case "version":
+		version, err := migrator.Version(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Println(version)
+	default:
+		return fmt.Errorf("unknown migrate command %q", cmd)
+	}
+
+	return nil
+}
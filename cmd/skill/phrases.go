@@ -0,0 +1,71 @@
+package main
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"time"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/humanize"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/i18n"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/templates"
+)
+
+// defaultLocale — язык ответов, если язык пользователя навык не знает.
+const defaultLocale = "ru-RU"
+
+// locales — каталоги фраз навыка, по файлу на локаль. У части фраз несколько
+// вариантов, навык выбирает один из них, чтобы речь не звучала заученно.
+// Первый вариант — основной, его видят тесты.
+//
+//go:embed locales/*.json
+var locales embed.FS
+
+// localeFallbacks — откуда брать фразы, которых нет в каталоге.
+// Турецкому пользователю английский понятнее русского,
+// остальным недостающие фразы говорятся по-русски.
+var localeFallbacks = map[string][]string{
+	"tr-TR": {"en-US"},
+}
+
+// loadCatalogs читает встроенные каталоги фраз.
+func loadCatalogs() (map[string]i18n.Catalog, error) {
+	fsys, err := fs.Sub(locales, "locales")
+	if err != nil {
+		return nil, err
+	}
+
+	return i18n.Load(fsys)
+}
+
+// newPhrases собирает фразы навыка на всех языках с заданным способом выбора варианта.
+func newPhrases(pick templates.Picker) *i18n.Bundle {
+	catalogs, err := loadCatalogs()
+	if err != nil {
+		panic(err)
+	}
+
+	b, err := i18n.New(catalogs, i18n.Options{
+		Default:   defaultLocale,
+		Fallbacks: localeFallbacks,
+		Pick:      pick,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// sentAt описывает время отправки сообщения на языке phrases: «вчера вечером».
+func sentAt(phrases *templates.Set, t, now time.Time, loc *time.Location) string {
+	m := humanize.Describe(t, now, loc)
+
+	return phrases.Render("time."+string(m.Kind), templates.Data{
+		"Count": m.Count,
+		"Clock": m.Time.Format("15:04"),
+		"Day":   m.Time.Day(),
+		"Month": phrases.Render(fmt.Sprintf("month.%d", m.Time.Month()), nil),
+		"Year":  m.Time.Year(),
+	})
+}
@@ -0,0 +1,16 @@
+// Package cache хранит значения с ограниченным временем жизни
+// в памяти процесса или в Redis.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache — хранилище значений по ключу.
+// Get возвращает false, если значения нет или его время жизни истекло.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
@@ -0,0 +1,253 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// redisPoolSize — сколько соединений с сервером клиент держит одновременно.
+const redisPoolSize = 8
+
+// Redis — минимальный клиент протокола Redis (RESP2) с командами GET, SET и DEL.
+// Держит небольшой пул соединений, чтобы параллельные запросы навыка
+// не выстраивались в очередь к одному соединению. Соединение после
+// сетевой ошибки закрывается, следующая команда откроет новое.
+type Redis struct {
+	addr    string
+	timeout time.Duration
+
+	// slots ограничивает число соединений, занятых командами
+	slots chan struct{}
+
+	mu     sync.Mutex
+	idle   []*redisConn
+	closed bool
+}
+
+// redisConn — соединение с сервером и буфер для чтения ответов.
+type redisConn struct {
+	conn net.Conn
+	rd   *bufio.Reader
+}
+
+// redisError — ошибка, которую вернул сервер Redis.
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// NewRedis создаёт клиент для сервера по адресу host:port или redis://host:port.
+// timeout ограничивает подключение и каждую команду, если в контексте нет своего срока.
+func NewRedis(addr string, timeout time.Duration) *Redis {
+	return &Redis{
+		addr:    strings.TrimPrefix(addr, "redis://"),
+		timeout: timeout,
+		slots:   make(chan struct{}, redisPoolSize),
+	}
+}
+
+func (r *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := r.do(ctx, "GET", key)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if reply == nil {
+		return nil, false, nil
+	}
+
+	value, ok := reply.([]byte)
+	if !ok {
+		return nil, false, fmt.Errorf("redis: unexpected GET reply %T", reply)
+	}
+
+	return value, true, nil
+}
+
+// Set запоминает значение на время ttl, округлённое вверх до миллисекунд:
+// Redis не принимает время жизни меньше миллисекунды.
+func (r *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	ms := (ttl + time.Millisecond - 1).Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+
+	_, err := r.do(ctx, "SET", key, string(value), "PX", strconv.FormatInt(ms, 10))
+	return err
+}
+
+func (r *Redis) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	_, err := r.do(ctx, append([]string{"DEL"}, keys...)...)
+	return err
+}
+
+// Close закрывает свободные соединения с сервером. Занятые командами
+// соединения закроются, когда команды завершатся.
+func (r *Redis) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closed = true
+
+	var err error
+	for _, c := range r.idle {
+		if closeErr := c.conn.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	r.idle = nil
+
+	return err
+}
+
+// do отправляет команду и читает ответ. Ответ — string, int64, []byte, []interface{} или nil.
+func (r *Redis) do(ctx context.Context, args ...string) (interface{}, error) {
+	select {
+	case r.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-r.slots }()
+
+	c, err := r.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(r.timeout)
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	if _, err := c.conn.Write([]byte(b.String())); err != nil {
+		c.conn.Close()
+		return nil, err
+	}
+
+	reply, err := readReply(c.rd)
+	// после ошибки сервера соединение остаётся рабочим,
+	// после сетевой ошибки или мусора в ответе — нет
+	var serverErr redisError
+	if err != nil && !errors.As(err, &serverErr) {
+		c.conn.Close()
+		return reply, err
+	}
+
+	r.release(c)
+	return reply, err
+}
+
+// acquire берёт свободное соединение из пула или открывает новое.
+func (r *Redis) acquire(ctx context.Context) (*redisConn, error) {
+	r.mu.Lock()
+	if n := len(r.idle); n > 0 {
+		c := r.idle[n-1]
+		r.idle = r.idle[:n-1]
+		r.mu.Unlock()
+		return c, nil
+	}
+	r.mu.Unlock()
+
+	d := net.Dialer{Timeout: r.timeout}
+	conn, err := d.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &redisConn{conn: conn, rd: bufio.NewReader(conn)}, nil
+}
+
+// release возвращает исправное соединение в пул.
+func (r *Redis) release(c *redisConn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed || len(r.idle) >= redisPoolSize {
+		c.conn.Close()
+		return
+	}
+	r.idle = append(r.idle, c)
+}
+
+// readReply читает один ответ в формате RESP2.
+func readReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || !strings.HasSuffix(line, "\r\n") {
+		return nil, fmt.Errorf("redis: malformed reply %q", line)
+	}
+
+	kind, body := line[0], line[1:len(line)-2]
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return nil, redisError(body)
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed bulk length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		return buf[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, fmt.Errorf("redis: malformed array length %q", body)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+
+		items := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			// ошибка элемента не прерывает чтение, иначе в соединении останется хвост ответа
+			item, err := readReply(rd)
+			var serverErr redisError
+			if errors.As(err, &serverErr) {
+				item, err = serverErr, nil
+			}
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	default:
+		return nil, fmt.Errorf("redis: unknown reply type %q", kind)
+	}
+}
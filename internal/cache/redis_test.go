@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/cache/redistest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	srv := redistest.NewServer(t)
+
+	c := NewRedis("redis://"+srv.Addr, time.Second)
+	defer c.Close()
+
+	_, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	require.NoError(t, c.Set(ctx, "a", []byte("значение\r\nс переводом строки"), time.Minute))
+	value, ok, err := c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "значение\r\nс переводом строки", string(value))
+
+	require.NoError(t, c.Set(ctx, "short", []byte("1"), time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	_, ok, err = c.Get(ctx, "short")
+	require.NoError(t, err)
+	assert.False(t, ok, "value expires after ttl")
+
+	// Redis не принимает PX 0, поэтому время жизни меньше миллисекунды округляется вверх
+	for _, ttl := range []time.Duration{time.Microsecond, 0, -time.Second} {
+		require.NoError(t, c.Set(ctx, "tiny", []byte("1"), ttl), ttl)
+	}
+
+	require.NoError(t, c.Delete(ctx, "a", "missing"))
+	_, ok, err = c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	_, err = c.do(ctx, "UNKNOWN")
+	assert.ErrorContains(t, err, "unknown command")
+
+	// после ошибки сервера и разрыва соединения клиент продолжает работать
+	srv.DropConnections()
+	_, _, err = c.Get(ctx, "a")
+	assert.Error(t, err)
+	require.NoError(t, c.Set(ctx, "a", []byte("1"), time.Minute))
+	value, ok, err = c.Get(ctx, "a")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []byte("1"), value)
+}
+
+func TestRedisPool(t *testing.T) {
+	ctx := context.Background()
+	srv := redistest.NewServer(t)
+
+	c := NewRedis(srv.Addr, time.Second)
+	defer c.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			key := fmt.Sprint("key", i)
+			assert.NoError(t, c.Set(ctx, key, []byte(key), time.Minute))
+			value, ok, err := c.Get(ctx, key)
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			assert.Equal(t, key, string(value))
+		}(i)
+	}
+	wg.Wait()
+
+	// параллельные команды идут по нескольким соединениям, но не больше размера пула
+	assert.LessOrEqual(t, srv.Accepted(), redisPoolSize)
+	assert.Equal(t, 100, srv.Commands())
+
+	// свободные соединения переиспользуются
+	accepted := srv.Accepted()
+	_, _, err := c.Get(ctx, "key1")
+	require.NoError(t, err)
+	assert.Equal(t, accepted, srv.Accepted())
+}
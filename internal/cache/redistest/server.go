@@ -0,0 +1,212 @@
+// Package redistest запускает локальный сервер с протоколом Redis для тестов.
+// Сервер понимает только GET, SET с PX, DEL и PING.
+package redistest
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Server хранит значения в памяти и отвечает по протоколу RESP2.
+type Server struct {
+	Addr string
+
+	ln net.Listener
+
+	mu       sync.Mutex
+	values   map[string]string
+	expires  map[string]time.Time
+	conns    map[net.Conn]struct{}
+	commands int
+	accepted int
+}
+
+// NewServer запускает сервер на свободном порту и останавливает его в конце теста.
+func NewServer(t *testing.T) *Server {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("cannot start fake redis: %v", err)
+	}
+
+	s := &Server{
+		Addr:    ln.Addr().String(),
+		ln:      ln,
+		values:  make(map[string]string),
+		expires: make(map[string]time.Time),
+		conns:   make(map[net.Conn]struct{}),
+	}
+	go s.serve()
+	t.Cleanup(s.Close)
+
+	return s
+}
+
+// Commands возвращает число выполненных команд.
+func (s *Server) Commands() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.commands
+}
+
+// Accepted возвращает число принятых соединений.
+func (s *Server) Accepted() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.accepted
+}
+
+// DropConnections разрывает все открытые соединения, как при перезапуске сервера.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for conn := range s.conns {
+		conn.Close()
+		delete(s.conns, conn)
+	}
+}
+
+func (s *Server) Close() {
+	s.ln.Close()
+	s.DropConnections()
+}
+
+func (s *Server) serve() {
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.accepted++
+		s.mu.Unlock()
+
+		go s.handle(conn)
+	}
+}
+
+func (s *Server) handle(conn net.Conn) {
+	defer conn.Close()
+
+	rd := bufio.NewReader(conn)
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+
+		if _, err := io.WriteString(conn, s.exec(args)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) exec(args []string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commands++
+	now := time.Now()
+	for key, at := range s.expires {
+		if !now.Before(at) {
+			delete(s.values, key)
+			delete(s.expires, key)
+		}
+	}
+
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		return "+PONG\r\n"
+	case "GET":
+		if len(args) != 2 {
+			return "-ERR wrong number of arguments for 'get' command\r\n"
+		}
+		value, ok := s.values[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		if len(args) != 3 && len(args) != 5 {
+			return "-ERR syntax error\r\n"
+		}
+		if len(args) == 5 {
+			ms, err := strconv.Atoi(args[4])
+			if !strings.EqualFold(args[3], "PX") || err != nil {
+				return "-ERR syntax error\r\n"
+			}
+			if ms <= 0 {
+				return "-ERR invalid expire time in 'set' command\r\n"
+			}
+			s.expires[args[1]] = now.Add(time.Duration(ms) * time.Millisecond)
+		} else {
+			delete(s.expires, args[1])
+		}
+		s.values[args[1]] = args[2]
+		return "+OK\r\n"
+	case "DEL":
+		n := 0
+		for _, key := range args[1:] {
+			if _, ok := s.values[key]; ok {
+				n++
+			}
+			delete(s.values, key)
+			delete(s.expires, key)
+		}
+		return fmt.Sprintf(":%d\r\n", n)
+	default:
+		return fmt.Sprintf("-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+// readCommand читает команду клиента — массив строк RESP.
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	n, err := readLength(rd, '*')
+	if err != nil {
+		return nil, err
+	}
+	if n < 1 {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		size, err := readLength(rd, '$')
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, err
+		}
+		args = append(args, string(buf[:size]))
+	}
+
+	return args, nil
+}
+
+func readLength(rd *bufio.Reader, prefix byte) (int, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return 0, err
+	}
+	if len(line) < 3 || line[0] != prefix {
+		return 0, fmt.Errorf("unexpected line %q", line)
+	}
+
+	return strconv.Atoi(strings.TrimSuffix(line[1:], "\r\n"))
+}
@@ -0,0 +1,60 @@
+package dialog
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrUnexpectedEvent возвращается, если из текущего состояния нет перехода по событию.
+var ErrUnexpectedEvent = errors.New("unexpected dialog event")
+
+// State — состояние диалога. Нулевое значение соответствует StateIdle.
+type State string
+
+// Event — событие, которое переводит диалог из одного состояния в другое.
+type Event string
+
+// StateIdle — диалог не ведётся, навык ждёт новую команду.
+const StateIdle State = ""
+
+// Transition описывает переход из состояния From по событию Event в состояние To.
+type Transition struct {
+	From  State
+	Event Event
+	To    State
+}
+
+// Flow — конечный автомат диалога, заданный списком переходов.
+type Flow struct {
+	transitions map[State]map[Event]State
+}
+
+// NewFlow создаёт автомат. Повторное объявление перехода из того же состояния
+// по тому же событию заменяет предыдущее.
+func NewFlow(transitions ...Transition) *Flow {
+	f := &Flow{transitions: make(map[State]map[Event]State)}
+	for _, t := range transitions {
+		if f.transitions[t.From] == nil {
+			f.transitions[t.From] = make(map[Event]State)
+		}
+		f.transitions[t.From][t.Event] = t.To
+	}
+
+	return f
+}
+
+// Fire возвращает состояние, в которое диалог переходит из from по событию e.
+func (f *Flow) Fire(from State, e Event) (State, error) {
+	to, ok := f.transitions[from][e]
+	if !ok {
+		return from, fmt.Errorf("%w %q in state %q", ErrUnexpectedEvent, e, from)
+	}
+
+	return to, nil
+}
+
+// Accepts сообщает, есть ли из состояния from переход по событию e.
+func (f *Flow) Accepts(from State, e Event) bool {
+	_, ok := f.transitions[from][e]
+	return ok
+}
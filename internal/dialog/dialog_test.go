@@ -0,0 +1,36 @@
+package dialog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlow(t *testing.T) {
+	const (
+		stateAsking State = "asking"
+		eventStart  Event = "start"
+		eventAnswer Event = "answer"
+	)
+
+	f := NewFlow(
+		Transition{From: StateIdle, Event: eventStart, To: stateAsking},
+		Transition{From: stateAsking, Event: eventAnswer, To: StateIdle},
+	)
+
+	s, err := f.Fire(StateIdle, eventStart)
+	require.NoError(t, err)
+	assert.Equal(t, stateAsking, s)
+
+	assert.True(t, f.Accepts(stateAsking, eventAnswer))
+	assert.False(t, f.Accepts(stateAsking, eventStart))
+
+	s, err = f.Fire(stateAsking, eventStart)
+	require.ErrorIs(t, err, ErrUnexpectedEvent)
+	assert.Equal(t, stateAsking, s)
+
+	s, err = f.Fire(stateAsking, eventAnswer)
+	require.NoError(t, err)
+	assert.Equal(t, StateIdle, s)
+}
@@ -0,0 +1,92 @@
+// Package humanize описывает время так, как его произносят люди:
+// «только что», «сегодня в 14:05», «вчера вечером», «3 дня назад».
+// Describe возвращает только вид описания, а слова для него берутся
+// из фраз навыка на языке пользователя.
+package humanize
+
+import "time"
+
+// Kind — вид описания момента времени.
+type Kind string
+
+const (
+	JustNow          Kind = "just_now"
+	MinutesAgo       Kind = "minutes_ago"
+	Today            Kind = "today"
+	YesterdayNight   Kind = "yesterday_night"
+	YesterdayMorning Kind = "yesterday_morning"
+	YesterdayDay     Kind = "yesterday_day"
+	YesterdayEvening Kind = "yesterday_evening"
+	DaysAgo          Kind = "days_ago"
+	ThisYear         Kind = "this_year"
+	LongAgo          Kind = "long_ago"
+)
+
+// Moment — момент времени, описанный относительно текущего.
+// По нему фраза строится на любом языке.
+type Moment struct {
+	Kind Kind
+	// Count — сколько минут или дней назад для MinutesAgo и DaysAgo.
+	Count int
+	// Time — сам момент в часовом поясе пользователя.
+	Time time.Time
+}
+
+// Describe описывает момент t относительно now в часовом поясе loc.
+// Дни считаются по календарю пользователя: сообщение, отправленное
+// в 23:30, в половине второго ночи уже «вчера вечером».
+func Describe(t, now time.Time, loc *time.Location) Moment {
+	t, now = t.In(loc), now.In(loc)
+	m := Moment{Time: t}
+
+	elapsed := now.Sub(t)
+	switch {
+	// время из будущего возможно при расхождении часов серверов
+	case elapsed < time.Minute:
+		m.Kind = JustNow
+		return m
+	case elapsed < time.Hour:
+		m.Kind, m.Count = MinutesAgo, int(elapsed/time.Minute)
+		return m
+	}
+
+	switch days := calendarDays(t, now); {
+	case days == 0:
+		m.Kind = Today
+	case days == 1:
+		m.Kind = yesterday(t)
+	case days < 7:
+		m.Kind, m.Count = DaysAgo, days
+	case t.Year() == now.Year():
+		m.Kind = ThisYear
+	default:
+		m.Kind = LongAgo
+	}
+
+	return m
+}
+
+// calendarDays возвращает, сколько полуночей прошло между t и now.
+func calendarDays(t, now time.Time) int {
+	y1, m1, d1 := t.Date()
+	y2, m2, d2 := now.Date()
+
+	// полдень в UTC не сдвигается переходом на летнее время
+	from := time.Date(y1, m1, d1, 12, 0, 0, 0, time.UTC)
+	to := time.Date(y2, m2, d2, 12, 0, 0, 0, time.UTC)
+	return int(to.Sub(from).Hours() / 24)
+}
+
+// yesterday выбирает часть вчерашнего дня.
+func yesterday(t time.Time) Kind {
+	switch h := t.Hour(); {
+	case h < 6:
+		return YesterdayNight
+	case h < 12:
+		return YesterdayMorning
+	case h < 18:
+		return YesterdayDay
+	default:
+		return YesterdayEvening
+	}
+}
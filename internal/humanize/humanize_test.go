@@ -0,0 +1,98 @@
+package humanize
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribe(t *testing.T) {
+	moscow, err := time.LoadLocation("Europe/Moscow")
+	require.NoError(t, err)
+
+	// 22 марта 2024, 10:00 по Москве
+	now := time.Date(2024, time.March, 22, 7, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name     string
+		t        time.Time
+		expected Moment
+	}{
+		{name: "now", t: now, expected: Moment{Kind: JustNow}},
+		{name: "future", t: now.Add(time.Minute), expected: Moment{Kind: JustNow}},
+		{name: "seconds", t: now.Add(-30 * time.Second), expected: Moment{Kind: JustNow}},
+		{name: "one_minute", t: now.Add(-time.Minute), expected: Moment{Kind: MinutesAgo, Count: 1}},
+		{name: "minutes", t: now.Add(-59 * time.Minute), expected: Moment{Kind: MinutesAgo, Count: 59}},
+		{name: "today", t: now.Add(-3*time.Hour - 55*time.Minute), expected: Moment{Kind: Today}},
+		{name: "after_midnight", t: now.Add(-10 * time.Hour), expected: Moment{Kind: Today}},
+		{name: "before_midnight", t: now.Add(-10*time.Hour - time.Second), expected: Moment{Kind: YesterdayEvening}},
+		{name: "yesterday_evening", t: now.Add(-16 * time.Hour), expected: Moment{Kind: YesterdayEvening}},
+		{name: "yesterday_day", t: now.Add(-16*time.Hour - time.Second), expected: Moment{Kind: YesterdayDay}},
+		{name: "yesterday_noon", t: now.Add(-22 * time.Hour), expected: Moment{Kind: YesterdayDay}},
+		{name: "yesterday_morning", t: now.Add(-22*time.Hour - time.Second), expected: Moment{Kind: YesterdayMorning}},
+		{name: "yesterday_dawn", t: now.Add(-28 * time.Hour), expected: Moment{Kind: YesterdayMorning}},
+		{name: "yesterday_night", t: now.Add(-28*time.Hour - time.Second), expected: Moment{Kind: YesterdayNight}},
+		{name: "yesterday_midnight", t: now.Add(-34 * time.Hour), expected: Moment{Kind: YesterdayNight}},
+		{name: "two_days", t: now.Add(-34*time.Hour - time.Second), expected: Moment{Kind: DaysAgo, Count: 2}},
+		{name: "six_days", t: now.Add(-6 * 24 * time.Hour), expected: Moment{Kind: DaysAgo, Count: 6}},
+		{name: "week", t: now.Add(-7 * 24 * time.Hour), expected: Moment{Kind: ThisYear}},
+		{name: "this_year", t: time.Date(2024, time.January, 1, 0, 0, 0, 0, moscow), expected: Moment{Kind: ThisYear}},
+		{name: "last_year", t: time.Date(2023, time.December, 31, 23, 59, 0, 0, moscow), expected: Moment{Kind: LongAgo}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := Describe(tc.t, now, moscow)
+			assert.Equal(t, tc.expected.Kind, m.Kind)
+			assert.Equal(t, tc.expected.Count, m.Count)
+			assert.True(t, tc.t.Equal(m.Time))
+			assert.Equal(t, moscow, m.Time.Location())
+		})
+	}
+}
+
+func TestDescribeMidnight(t *testing.T) {
+	// отправлено в 23:30, прошло полтора часа: по календарю это уже вчера
+	sent := time.Date(2024, time.March, 21, 23, 30, 0, 0, time.UTC)
+	now := sent.Add(90 * time.Minute)
+	assert.Equal(t, YesterdayEvening, Describe(sent, now, time.UTC).Kind)
+
+	// а 59 минут спустя — ещё минуты назад, хотя дата уже сменилась
+	m := Describe(sent, sent.Add(59*time.Minute), time.UTC)
+	assert.Equal(t, MinutesAgo, m.Kind)
+	assert.Equal(t, 59, m.Count)
+}
+
+func TestDescribeLocation(t *testing.T) {
+	// 22 марта 2024, 07:00 UTC; сообщение отправлено в 23:30 UTC накануне
+	now := time.Date(2024, time.March, 22, 7, 0, 0, 0, time.UTC)
+	sent := now.Add(-7*time.Hour - 30*time.Minute)
+
+	assert.Equal(t, YesterdayEvening, Describe(sent, now, time.UTC).Kind)
+
+	// во Владивостоке (UTC+10) это 09:30 того же дня, что и сейчас
+	vladivostok, err := time.LoadLocation("Asia/Vladivostok")
+	require.NoError(t, err)
+	m := Describe(sent, now, vladivostok)
+	assert.Equal(t, Today, m.Kind)
+	assert.Equal(t, 9, m.Time.Hour())
+
+	// в Нью-Йорке (UTC-4) сейчас 03:00, а сообщение отправлено накануне в 19:30
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	assert.Equal(t, YesterdayEvening, Describe(sent, now, newYork).Kind)
+}
+
+func TestDescribeDST(t *testing.T) {
+	// в Берлине в ночь на 31 марта 2024 часы перевели вперёд, сутки длились 23 часа
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	sent := time.Date(2024, time.March, 30, 12, 0, 0, 0, berlin)
+	now := time.Date(2024, time.April, 1, 11, 0, 0, 0, berlin)
+	m := Describe(sent, now, berlin)
+	assert.Equal(t, DaysAgo, m.Kind)
+	assert.Equal(t, 2, m.Count)
+}
@@ -0,0 +1,183 @@
+// Package i18n выбирает фразы навыка на языке пользователя.
+//
+// Фразы каждого языка лежат в отдельном каталоге — JSON-файле с именем
+// по локали, например ru-RU.json:
+//
+//	{
+//	    "greeting.no_unread": ["Для вас нет новых сообщений.", "Новых сообщений пока нет."]
+//	}
+//
+// Значение — варианты шаблона фразы для пакета templates.
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/templates"
+)
+
+// Catalog — фразы одного языка: для каждого имени один или несколько вариантов.
+type Catalog map[string][]string
+
+// pluralRules — правила множественного числа по коду языка.
+var pluralRules = map[string]templates.PluralRule{
+	"ru": templates.Russian,
+	"en": templates.OneOther,
+	"tr": templates.OneOther,
+	"kk": templates.OneOther,
+}
+
+// Load читает каталоги из файлов *.json в корне fsys.
+// Ключ результата — локаль из имени файла.
+func Load(fsys fs.FS) (map[string]Catalog, error) {
+	files, err := fs.Glob(fsys, "*.json")
+	if err != nil {
+		return nil, fmt.Errorf("cannot list catalogs: %w", err)
+	}
+
+	catalogs := make(map[string]Catalog, len(files))
+	for _, name := range files {
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read catalog %s: %w", name, err)
+		}
+
+		var c Catalog
+		if err := json.Unmarshal(data, &c); err != nil {
+			return nil, fmt.Errorf("cannot parse catalog %s: %w", name, err)
+		}
+
+		catalogs[Normalize(strings.TrimSuffix(path.Base(name), ".json"))] = c
+	}
+
+	return catalogs, nil
+}
+
+// Options — настройки выбора языка.
+type Options struct {
+	// Default — локаль, на которой навык отвечает, если язык пользователя не поддерживается.
+	// Её каталог должен быть полным: им дополняются все остальные.
+	Default string
+	// Fallbacks — какие локали пробовать, если в каталоге нет фразы или
+	// нет каталога для локали пользователя. Ключ — локаль или код языка:
+	// "kk-KZ": {"ru-RU"}, "uk": {"ru-RU"}. После цепочки всегда идёт Default.
+	Fallbacks map[string][]string
+	// Pick выбирает вариант фразы, по умолчанию templates.First.
+	Pick templates.Picker
+}
+
+// Bundle — фразы на всех поддерживаемых языках.
+type Bundle struct {
+	sets       map[string]*templates.Set
+	byLanguage map[string]string
+	opts       Options
+}
+
+// New собирает фразы из каталогов. Фразы, которых нет в каталоге,
+// берутся из следующих каталогов цепочки локали.
+func New(catalogs map[string]Catalog, opts Options) (*Bundle, error) {
+	opts.Default = Normalize(opts.Default)
+	if _, ok := catalogs[opts.Default]; !ok {
+		return nil, fmt.Errorf("no catalog for default locale %q", opts.Default)
+	}
+	if opts.Pick == nil {
+		opts.Pick = templates.First
+	}
+	fallbacks := make(map[string][]string, len(opts.Fallbacks))
+	for locale, chain := range opts.Fallbacks {
+		key := Normalize(locale)
+		for _, tag := range chain {
+			fallbacks[key] = append(fallbacks[key], Normalize(tag))
+		}
+	}
+	opts.Fallbacks = fallbacks
+
+	b := &Bundle{
+		sets:       make(map[string]*templates.Set, len(catalogs)),
+		byLanguage: make(map[string]string, len(catalogs)),
+		opts:       opts,
+	}
+
+	// при нескольких каталогах одного языка предпочтём первый по алфавиту
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(locales)))
+	for _, locale := range locales {
+		b.byLanguage[language(locale)] = locale
+	}
+
+	for _, locale := range locales {
+		rule, ok := pluralRules[language(locale)]
+		if !ok {
+			return nil, fmt.Errorf("no plural rules for locale %q", locale)
+		}
+
+		phrases := make(map[string][]string)
+		for _, tag := range b.chain(locale) {
+			for name, variants := range catalogs[tag] {
+				if _, ok := phrases[name]; !ok {
+					phrases[name] = variants
+				}
+			}
+		}
+
+		set, err := templates.New(rule, phrases, opts.Pick)
+		if err != nil {
+			return nil, fmt.Errorf("cannot build phrases for %s: %w", locale, err)
+		}
+		b.sets[locale] = set
+	}
+
+	return b, nil
+}
+
+// For возвращает фразы для локали пользователя, например "ru-RU".
+// Неизвестная локаль заменяется первой подходящей из цепочки.
+func (b *Bundle) For(locale string) *templates.Set {
+	for _, tag := range b.chain(Normalize(locale)) {
+		if set, ok := b.sets[tag]; ok {
+			return set
+		}
+	}
+
+	return b.sets[b.opts.Default]
+}
+
+// chain возвращает локали, которые пробуются для locale по порядку:
+// сама локаль, каталог того же языка, заданные цепочки и локаль по умолчанию.
+func (b *Bundle) chain(locale string) []string {
+	lang := language(locale)
+	chain := []string{locale}
+	if tag, ok := b.byLanguage[lang]; ok && tag != locale {
+		chain = append(chain, tag)
+	}
+	chain = append(chain, b.opts.Fallbacks[locale]...)
+	if lang != locale {
+		chain = append(chain, b.opts.Fallbacks[lang]...)
+	}
+
+	return append(chain, b.opts.Default)
+}
+
+// Normalize приводит локаль к виду "ru-RU": Алиса и файлы каталогов
+// могут записывать её как "ru_ru" или "RU-ru".
+func Normalize(locale string) string {
+	lang, region, ok := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	if !ok {
+		return strings.ToLower(lang)
+	}
+
+	return strings.ToLower(lang) + "-" + strings.ToUpper(region)
+}
+
+func language(locale string) string {
+	lang, _, _ := strings.Cut(locale, "-")
+	return lang
+}
@@ -0,0 +1,94 @@
+package i18n
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/templates"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"ru-RU.json": {Data: []byte(`{"hello": ["Привет!", "Здравствуйте!"]}`)},
+		"en_us.json": {Data: []byte(`{"hello": ["Hello!"]}`)},
+		"README.md":  {Data: []byte(`not a catalog`)},
+	}
+
+	catalogs, err := Load(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]Catalog{
+		"ru-RU": {"hello": {"Привет!", "Здравствуйте!"}},
+		"en-US": {"hello": {"Hello!"}},
+	}, catalogs)
+
+	_, err = Load(fstest.MapFS{"ru-RU.json": {Data: []byte(`{"hello": "Привет!"}`)}})
+	assert.Error(t, err, "variants must be a list")
+}
+
+func TestBundle(t *testing.T) {
+	catalogs := map[string]Catalog{
+		"ru-RU": {
+			"hello":  {"Привет!"},
+			"unread": {`{{count .Count "сообщение" "сообщения" "сообщений"}}`},
+			"bye":    {"Пока!"},
+		},
+		"en-US": {
+			"hello":  {"Hello!"},
+			"unread": {`{{count .Count "message" "messages"}}`},
+		},
+		"tr-TR": {
+			"hello": {"Merhaba!"},
+		},
+		"kk-KZ": {
+			"hello": {"Сәлем!"},
+		},
+	}
+
+	b, err := New(catalogs, Options{
+		Default: "ru-RU",
+		Fallbacks: map[string][]string{
+			"tr-TR": {"en-US"},
+			"uk":    {"ru-RU"},
+			"de":    {"en-US"},
+		},
+	})
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		locale   string
+		phrase   string
+		expected string
+	}{
+		{name: "exact", locale: "en-US", phrase: "hello", expected: "Hello!"},
+		{name: "case", locale: "EN_us", phrase: "hello", expected: "Hello!"},
+		{name: "same_language", locale: "en-GB", phrase: "hello", expected: "Hello!"},
+		{name: "language_fallback", locale: "de-DE", phrase: "hello", expected: "Hello!"},
+		{name: "unknown", locale: "fr-FR", phrase: "hello", expected: "Привет!"},
+		{name: "empty", locale: "", phrase: "hello", expected: "Привет!"},
+		{name: "plural", locale: "en-US", phrase: "unread", expected: "2 messages"},
+		{name: "missing_phrase_chain", locale: "tr-TR", phrase: "unread", expected: "2 messages"},
+		{name: "missing_phrase_default", locale: "kk-KZ", phrase: "bye", expected: "Пока!"},
+		{name: "missing_phrase_default_plural", locale: "kk-KZ", phrase: "unread", expected: "2 сообщения"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, b.For(tc.locale).Render(tc.phrase, templates.Data{"Count": 2}))
+		})
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New(map[string]Catalog{"en-US": {"hello": {"Hello!"}}}, Options{Default: "ru-RU"})
+	assert.Error(t, err, "default catalog is required")
+
+	_, err = New(map[string]Catalog{"ru-RU": {"hello": {"Привет!"}}, "xx-XX": {}}, Options{Default: "ru-RU"})
+	assert.Error(t, err, "plural rules are required")
+
+	_, err = New(map[string]Catalog{"ru-RU": {"hello": {"{{.Name"}}}, Options{Default: "ru-RU"})
+	assert.Error(t, err, "templates must parse")
+}
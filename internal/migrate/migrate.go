@@ -0,0 +1,241 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// ErrNoMigrations возвращается, если в каталоге нет ни одной миграции.
+var ErrNoMigrations = errors.New("no migrations found")
+
+// Migration — одна версия схемы. Файлы миграций называются
+// 0001_create_users.up.sql и 0001_create_users.down.sql.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Locker не даёт нескольким экземплярам навыка применять миграции одновременно.
+// Блокировка берётся на соединении conn, на нём же выполняются миграции.
+type Locker interface {
+	Lock(ctx context.Context, conn *sql.Conn) error
+	Unlock(ctx context.Context, conn *sql.Conn) error
+}
+
+// Migrator применяет и откатывает миграции, записывая применённые версии
+// в таблицу schema_migrations.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	locker     Locker
+}
+
+var fileName = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Load читает миграции из корня fsys и сортирует их по версии.
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int64]*Migration)
+	for _, e := range entries {
+		match := fileName.FindStringSubmatch(e.Name())
+		if e.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad migration version in %q: %w", e.Name(), err)
+		}
+
+		body, err := fs.ReadFile(fsys, path.Join(".", e.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names: %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(body)
+		} else {
+			m.Down = string(body)
+		}
+	}
+
+	if len(byVersion) == 0 {
+		return nil, ErrNoMigrations
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// New создаёт Migrator для миграций из fsys. locker может быть nil,
+// если база не поддерживает блокировки или навык запущен в одном экземпляре.
+func New(db *sql.DB, fsys fs.FS, locker Locker) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		locker:     locker,
+	}, nil
+}
+
+// Up применяет все ещё не применённые миграции и возвращает их версии.
+func (m *Migrator) Up(ctx context.Context) ([]int64, error) {
+	var applied []int64
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		current, err := version(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, mg := range m.migrations {
+			if mg.Version <= current {
+				continue
+			}
+
+			if err := apply(ctx, conn, mg.Up, `insert into schema_migrations (version) values ($1)`, mg.Version); err != nil {
+				return fmt.Errorf("cannot apply migration %d_%s: %w", mg.Version, mg.Name, err)
+			}
+			applied = append(applied, mg.Version)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down откатывает steps последних применённых миграций и возвращает их версии.
+func (m *Migrator) Down(ctx context.Context, steps int) ([]int64, error) {
+	var reverted []int64
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			mg := m.migrations[i]
+
+			current, err := version(ctx, conn)
+			if err != nil {
+				return err
+			}
+			if mg.Version > current {
+				continue
+			}
+			if mg.Down == "" {
+				return fmt.Errorf("migration %d_%s has no down script", mg.Version, mg.Name)
+			}
+
+			if err := apply(ctx, conn, mg.Down, `delete from schema_migrations where version = $1`, mg.Version); err != nil {
+				return fmt.Errorf("cannot revert migration %d_%s: %w", mg.Version, mg.Name, err)
+			}
+			reverted = append(reverted, mg.Version)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Version возвращает последнюю применённую версию схемы или 0, если миграций не было.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	var v int64
+	err := m.withLock(ctx, func(conn *sql.Conn) (err error) {
+		v, err = version(ctx, conn)
+		return err
+	})
+
+	return v, err
+}
+
+// withLock выполняет fn на отдельном соединении под блокировкой
+// и создаёт таблицу schema_migrations, если её ещё нет.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) (err error) {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if m.locker != nil {
+		if err := m.locker.Lock(ctx, conn); err != nil {
+			return fmt.Errorf("cannot acquire migration lock: %w", err)
+		}
+		defer func() {
+			if unlockErr := m.locker.Unlock(context.Background(), conn); unlockErr != nil && err == nil {
+				err = fmt.Errorf("cannot release migration lock: %w", unlockErr)
+			}
+		}()
+	}
+
+	_, err = conn.ExecContext(ctx, `
+		create table if not exists schema_migrations (
+		    version bigint primary key,
+		    applied_at timestamp with time zone not null default current_timestamp
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("cannot create schema_migrations: %w", err)
+	}
+
+	return fn(conn)
+}
+
+func version(ctx context.Context, conn *sql.Conn) (int64, error) {
+	var v int64
+	err := conn.QueryRowContext(ctx, `select coalesce(max(version), 0) from schema_migrations`).Scan(&v)
+	return v, err
+}
+
+// apply выполняет скрипт миграции и запись о версии в одной транзакции.
+func apply(ctx context.Context, conn *sql.Conn, script, record string, version int64) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, record, version); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
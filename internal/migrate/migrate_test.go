@@ -0,0 +1,226 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	_ "modernc.org/sqlite"
+)
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"0002_add_index.up.sql":   {Data: []byte("create index i on t (c)")},
+		"0002_add_index.down.sql": {Data: []byte("drop index i")},
+		"0001_init.up.sql":        {Data: []byte("create table t (c int)")},
+		"0001_init.down.sql":      {Data: []byte("drop table t")},
+		"README.md":               {Data: []byte("not a migration")},
+	}
+
+	migrations, err := Load(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Name: "init", Up: "create table t (c int)", Down: "drop table t"},
+		{Version: 2, Name: "add_index", Up: "create index i on t (c)", Down: "drop index i"},
+	}, migrations)
+
+	_, err = Load(fstest.MapFS{})
+	assert.ErrorIs(t, err, ErrNoMigrations)
+
+	_, err = Load(fstest.MapFS{"0001_init.down.sql": {Data: []byte("drop table t")}})
+	assert.Error(t, err, "migration without up script")
+
+	_, err = Load(fstest.MapFS{
+		"0001_init.up.sql":  {Data: []byte("create table t (c int)")},
+		"0001_other.up.sql": {Data: []byte("create table o (c int)")},
+	})
+	assert.Error(t, err, "two migrations with the same version")
+}
+
+// newTestDB открывает пустую базу SQLite во временном каталоге.
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "migrate.db")+"?_pragma=busy_timeout(5000)")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+var testMigrations = fstest.MapFS{
+	"0001_users.up.sql":      {Data: []byte("create table users (id text primary key)")},
+	"0001_users.down.sql":    {Data: []byte("drop table users")},
+	"0002_names.up.sql":      {Data: []byte("alter table users add column name text")},
+	"0002_names.down.sql":    {Data: []byte("alter table users drop column name")},
+	"0003_messages.up.sql":   {Data: []byte("create table messages (id integer primary key, sender text references users (id))")},
+	"0003_messages.down.sql": {Data: []byte("drop table messages")},
+}
+
+func TestUpDown(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	m, err := New(db, testMigrations, nil)
+	require.NoError(t, err)
+
+	v, err := m.Version(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, v)
+
+	applied, err := m.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, applied)
+
+	v, err = m.Version(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, v)
+	_, err = db.Exec(`insert into users (id, name) values ('masha-id', 'маша')`)
+	require.NoError(t, err)
+
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, applied, "applied migrations are not run again")
+
+	reverted, err := m.Down(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{3, 2}, reverted)
+
+	v, err = m.Version(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, v)
+	_, err = db.Exec(`insert into users (id, name) values ('petya-id', 'петя')`)
+	assert.Error(t, err, "column from reverted migration is gone")
+
+	reverted, err = m.Down(ctx, 10)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1}, reverted)
+
+	v, err = m.Version(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, v)
+
+	applied, err = m.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2, 3}, applied)
+}
+
+func TestUpFailure(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	fsys := fstest.MapFS{
+		"0001_users.up.sql":  {Data: []byte("create table users (id text primary key)")},
+		"0002_broken.up.sql": {Data: []byte("create table messages (id integer primary key); alter table nope add column c text")},
+	}
+	m, err := New(db, fsys, nil)
+	require.NoError(t, err)
+
+	applied, err := m.Up(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "migration 2_broken")
+	assert.Equal(t, []int64{1}, applied)
+
+	// неудачная миграция откатывается целиком вместе с записью о версии
+	v, err := m.Version(ctx)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, v)
+	_, err = db.Exec(`select count(*) from messages`)
+	assert.Error(t, err)
+
+	_, err = m.Down(ctx, 1)
+	assert.Error(t, err, "migration without down script cannot be reverted")
+}
+
+// mutexLocker — Locker для тестов: блокировка внутри процесса
+// вместо advisory-блокировки Postgres.
+type mutexLocker struct {
+	mu      sync.Mutex
+	locks   int32
+	unlocks int32
+	err     error
+}
+
+func (l *mutexLocker) Lock(ctx context.Context, conn *sql.Conn) error {
+	if l.err != nil {
+		return l.err
+	}
+
+	l.mu.Lock()
+	atomic.AddInt32(&l.locks, 1)
+	return nil
+}
+
+func (l *mutexLocker) Unlock(ctx context.Context, conn *sql.Conn) error {
+	atomic.AddInt32(&l.unlocks, 1)
+	l.mu.Unlock()
+	return nil
+}
+
+func TestConcurrentUp(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+	locker := &mutexLocker{}
+
+	const instances = 8
+	results := make(chan []int64, instances)
+	errs := make(chan error, instances)
+
+	var wg sync.WaitGroup
+	for i := 0; i < instances; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// у каждого экземпляра навыка свой Migrator
+			m, err := New(db, testMigrations, locker)
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			applied, err := m.Up(ctx)
+			errs <- err
+			results <- applied
+		}()
+	}
+	wg.Wait()
+	close(results)
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	// каждую миграцию применил ровно один экземпляр
+	var all []int64
+	for applied := range results {
+		all = append(all, applied...)
+	}
+	assert.ElementsMatch(t, []int64{1, 2, 3}, all)
+
+	assert.EqualValues(t, instances, locker.locks)
+	assert.EqualValues(t, instances, locker.unlocks, "every lock is released")
+}
+
+func TestLockFailure(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t)
+
+	m, err := New(db, testMigrations, &mutexLocker{err: errors.New("lock timeout")})
+	require.NoError(t, err)
+
+	applied, err := m.Up(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "lock timeout")
+	assert.Empty(t, applied)
+
+	_, err = db.Exec(`select count(*) from users`)
+	assert.Error(t, err, "nothing is applied without the lock")
+}
@@ -0,0 +1,77 @@
+package models
+
+// Типы карточек в ответе навыка.
+const (
+	CardBigImage  = "BigImage"
+	CardItemsList = "ItemsList"
+)
+
+// MaxListItems — максимальное число элементов в карточке ItemsList.
+const MaxListItems = 5
+
+// Card — карточка с изображениями, которую Алиса показывает на устройствах с экраном.
+// Набор заполняемых полей зависит от Type.
+type Card struct {
+	Type string `json:"type"`
+
+	// поля карточки BigImage
+	ImageID     string      `json:"image_id,omitempty"`
+	Title       string      `json:"title,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Button      *CardButton `json:"button,omitempty"`
+
+	// поля карточки ItemsList
+	Header *CardHeader `json:"header,omitempty"`
+	Items  []CardItem  `json:"items,omitempty"`
+	Footer *CardFooter `json:"footer,omitempty"`
+}
+
+type CardHeader struct {
+	Text string `json:"text"`
+}
+
+type CardItem struct {
+	ImageID     string      `json:"image_id,omitempty"`
+	Title       string      `json:"title,omitempty"`
+	Description string      `json:"description,omitempty"`
+	Button      *CardButton `json:"button,omitempty"`
+}
+
+type CardFooter struct {
+	Text   string      `json:"text"`
+	Button *CardButton `json:"button,omitempty"`
+}
+
+// CardButton — действие при нажатии на карточку или её элемент.
+type CardButton struct {
+	Text    string         `json:"text,omitempty"`
+	URL     string         `json:"url,omitempty"`
+	Payload *ButtonPayload `json:"payload,omitempty"`
+}
+
+// NewBigImage создаёт карточку с одним большим изображением.
+func NewBigImage(imageID, title, description string) *Card {
+	return &Card{
+		Type:        CardBigImage,
+		ImageID:     imageID,
+		Title:       title,
+		Description: description,
+	}
+}
+
+// NewItemsList создаёт карточку-список. Элементы сверх MaxListItems отбрасываются.
+func NewItemsList(header string, items []CardItem) *Card {
+	if len(items) > MaxListItems {
+		items = items[:MaxListItems]
+	}
+
+	c := &Card{
+		Type:  CardItemsList,
+		Items: items,
+	}
+	if header != "" {
+		c.Header = &CardHeader{Text: header}
+	}
+
+	return c
+}
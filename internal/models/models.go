@@ -1,30 +1,178 @@
+// Package models описывает протокол Яндекс Диалогов версии 1.0:
+// запрос Алисы к навыку и ответ навыка.
 package models
 
+import "encoding/json"
+
+// ProtocolVersion — версия протокола, которую поддерживает пакет.
+// Навык указывает её в каждом ответе.
+const ProtocolVersion = "1.0"
+
 const (
 	TypeSimpleUtterance = "SimpleUtterance"
+	TypeButtonPressed   = "ButtonPressed"
+)
+
+// Действия кнопок навыка, передаются в ButtonPayload.Action.
+const (
+	ActionRead   = "read"
+	ActionReply  = "reply"
+	ActionDelete = "delete"
 )
 
 type Request struct {
+	Meta     Meta            `json:"meta"`
 	Request  SimpleUtterance `json:"request"`
 	Timezone string          `json:"timezone"`
 	Session  Session         `json:"session"`
+	State    State           `json:"state"`
 	Version  string          `json:"version"`
 }
 
+// Meta — сведения об устройстве пользователя.
+type Meta struct {
+	// Locale — язык и регион пользователя, например "ru-RU".
+	Locale string `json:"locale"`
+	// Timezone — часовой пояс устройства, например "Europe/Moscow".
+	Timezone string `json:"timezone,omitempty"`
+	// ClientID — приложение, из которого пришёл запрос, например
+	// "ru.yandex.searchplugin/7.16 (none none; android 4.4.2)".
+	ClientID   string     `json:"client_id,omitempty"`
+	Interfaces Interfaces `json:"interfaces"`
+}
+
+// Interfaces — возможности устройства пользователя.
+// Алиса присылает пустой объект для каждой поддерживаемой возможности.
+type Interfaces struct {
+	// Screen — экран для кнопок и карточек. Его нет у колонок.
+	Screen *struct{} `json:"screen,omitempty"`
+	// AccountLinking — устройство позволяет связать аккаунт пользователя с навыком.
+	AccountLinking *struct{} `json:"account_linking,omitempty"`
+	// AudioPlayer — устройство умеет проигрывать длинные аудиозаписи.
+	AudioPlayer *struct{} `json:"audio_player,omitempty"`
+}
+
+// Username возвращает имя пользователя, сохранённое в состоянии навыка.
+func (r Request) Username() string {
+	if r.State.User.Username != "" {
+		return r.State.User.Username
+	}
+
+	return r.State.Application.Username
+}
+
+// MailboxID возвращает идентификатор, под которым навык хранит сообщения пользователя:
+// UserID пользователя, авторизованного в Яндексе, иначе — идентификатор приложения.
+// Без него неавторизованные пользователи делили бы один почтовый ящик.
+// Идентификатор приложения получает префикс, чтобы не совпасть с UserID.
+// Пустая строка означает, что пользователя не опознать.
+func (r Request) MailboxID() string {
+	if r.Session.User.UserID != "" {
+		return r.Session.User.UserID
+	}
+	if r.Session.Application.ApplicationID != "" {
+		return "app:" + r.Session.Application.ApplicationID
+	}
+
+	return ""
+}
+
+// UserTimezone возвращает название часового пояса пользователя:
+// из запроса, а если его нет — из сведений об устройстве.
+func (r Request) UserTimezone() string {
+	if r.Timezone != "" {
+		return r.Timezone
+	}
+
+	return r.Meta.Timezone
+}
+
 type Session struct {
-	New bool `json:"new"`
+	// SessionID — идентификатор сессии, не меняется до её конца.
+	SessionID string `json:"session_id"`
+	// MessageID — номер запроса в сессии, начиная с нуля.
+	MessageID int    `json:"message_id"`
+	SkillID   string `json:"skill_id"`
+	New       bool   `json:"new"`
+	// User — пользователь, авторизованный в Яндексе. Для остальных UserID пуст.
+	User        User        `json:"user"`
+	Application Application `json:"application"`
+}
+
+type User struct {
+	UserID string `json:"user_id"`
+	// AccessToken — токен связанного аккаунта, если пользователь его связал.
+	AccessToken string `json:"access_token,omitempty"`
+}
+
+// Application — экземпляр приложения, в котором запущен навык.
+// Идентификатор есть у каждого запроса, даже без авторизации в Яндексе.
+type Application struct {
+	ApplicationID string `json:"application_id"`
 }
 
 type SimpleUtterance struct {
-	Type    string `json:"type"`
-	Command string `json:"command"`
+	Type              string          `json:"type"`
+	Command           string          `json:"command"`
+	OriginalUtterance string          `json:"original_utterance"`
+	NLU               NLU             `json:"nlu"`
+	Payload           json.RawMessage `json:"payload,omitempty"`
+}
+
+// ButtonPayload — payload кнопок навыка.
+// Алиса возвращает его без изменений в запросе типа ButtonPressed.
+type ButtonPayload struct {
+	Action    string `json:"action"`
+	Index     int    `json:"index,omitempty"`
+	MessageID int64  `json:"message_id,omitempty"`
+}
+
+// ButtonPayload возвращает payload нажатой кнопки.
+func (u SimpleUtterance) ButtonPayload() (ButtonPayload, bool) {
+	var p ButtonPayload
+	if u.Type != TypeButtonPressed || len(u.Payload) == 0 {
+		return p, false
+	}
+
+	ok := json.Unmarshal(u.Payload, &p) == nil && p.Action != ""
+	return p, ok
 }
 
 type Response struct {
 	Response ResponsePayload `json:"response"`
-	Version  string          `json:"version"`
+	// SessionState заменяет состояние сессии целиком, пустое значение его сбрасывает.
+	SessionState *SessionState `json:"session_state,omitempty"`
+	// UserStateUpdate обновляет только переданные поля состояния пользователя.
+	UserStateUpdate  *UserState        `json:"user_state_update,omitempty"`
+	ApplicationState *ApplicationState `json:"application_state,omitempty"`
+	Version          string            `json:"version"`
 }
 
+// ResponsePayload — ответ пользователю.
 type ResponsePayload struct {
 	Text string `json:"text"`
+	// TTS — текст для синтеза речи с паузами и звуками, см. пакет tts.
+	// Если не задан, Алиса произносит Text.
+	TTS        string   `json:"tts,omitempty"`
+	Card       *Card    `json:"card,omitempty"`
+	Buttons    []Button `json:"buttons,omitempty"`
+	EndSession bool     `json:"end_session"`
+}
+
+// Button — кнопка под ответом навыка.
+// Если Hide равен true, кнопка показывается как подсказка и скрывается после ответа пользователя.
+type Button struct {
+	Title   string         `json:"title"`
+	Payload *ButtonPayload `json:"payload,omitempty"`
+	URL     string         `json:"url,omitempty"`
+	Hide    bool           `json:"hide,omitempty"`
+}
+
+// Adapt убирает из ответа то, что устройство не умеет показать:
+// на колонках без экрана нет ни карточек, ни кнопок.
+func (p *ResponsePayload) Adapt(i Interfaces) {
+	if i.Screen == nil {
+		p.Card = nil
+		p.Buttons = nil
+	}
 }
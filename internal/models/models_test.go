@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeRequest(t *testing.T) {
+	body := `{
+		"meta": {
+			"locale": "ru-RU",
+			"timezone": "Europe/Moscow",
+			"client_id": "ru.yandex.searchplugin/7.16 (none none; android 4.4.2)",
+			"interfaces": {"screen": {}, "account_linking": {}, "audio_player": {}}
+		},
+		"request": {"type": "SimpleUtterance", "command": "привет", "original_utterance": "Привет"},
+		"session": {
+			"message_id": 3,
+			"session_id": "2eac4854-fce721f3-b845abba-20d60",
+			"skill_id": "3ad36498-f5rd-4079-a14b-788652932056",
+			"new": false,
+			"user": {"user_id": "6C91DA5198D1758C6A9F63A7C5CDDF09359F683B13A18A151FBF4C8B092BB0C2", "access_token": "AgAAAAAB4vpbAAApoR1oaCd5yR6eiXSHqOGT8dT"},
+			"application": {"application_id": "47C73714B580ED2469056E71081159529FFC676A4E5B059D629A819E857DC2F8"}
+		},
+		"version": "1.0"
+	}`
+
+	var req Request
+	require.NoError(t, json.Unmarshal([]byte(body), &req))
+
+	assert.Equal(t, "ru-RU", req.Meta.Locale)
+	assert.Equal(t, "ru.yandex.searchplugin/7.16 (none none; android 4.4.2)", req.Meta.ClientID)
+	assert.NotNil(t, req.Meta.Interfaces.Screen)
+	assert.NotNil(t, req.Meta.Interfaces.AccountLinking)
+	assert.NotNil(t, req.Meta.Interfaces.AudioPlayer)
+	assert.Equal(t, "Europe/Moscow", req.UserTimezone())
+
+	assert.Equal(t, "2eac4854-fce721f3-b845abba-20d60", req.Session.SessionID)
+	assert.Equal(t, 3, req.Session.MessageID)
+	assert.Equal(t, "3ad36498-f5rd-4079-a14b-788652932056", req.Session.SkillID)
+	assert.Equal(t, "AgAAAAAB4vpbAAApoR1oaCd5yR6eiXSHqOGT8dT", req.Session.User.AccessToken)
+	assert.Equal(t, "47C73714B580ED2469056E71081159529FFC676A4E5B059D629A819E857DC2F8", req.Session.Application.ApplicationID)
+	assert.Equal(t, ProtocolVersion, req.Version)
+
+	// часовой пояс из запроса важнее часового пояса устройства
+	req.Timezone = "Asia/Vladivostok"
+	assert.Equal(t, "Asia/Vladivostok", req.UserTimezone())
+}
+
+func TestMailboxID(t *testing.T) {
+	var req Request
+	assert.Empty(t, req.MailboxID())
+
+	req.Session.Application.ApplicationID = "47C73714"
+	assert.Equal(t, "app:47C73714", req.MailboxID())
+
+	req.Session.User.UserID = "6C91DA51"
+	assert.Equal(t, "6C91DA51", req.MailboxID())
+}
+
+func TestAdapt(t *testing.T) {
+	payload := func() ResponsePayload {
+		return ResponsePayload{
+			Text:    "Новые сообщения",
+			Card:    NewItemsList("Новые сообщения", nil),
+			Buttons: []Button{{Title: "Прочитать"}},
+		}
+	}
+
+	p := payload()
+	p.Adapt(Interfaces{Screen: &struct{}{}})
+	assert.Equal(t, payload(), p)
+
+	p = payload()
+	p.Adapt(Interfaces{AudioPlayer: &struct{}{}})
+	assert.Equal(t, ResponsePayload{Text: "Новые сообщения"}, p)
+}
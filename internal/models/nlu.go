@@ -0,0 +1,126 @@
+package models
+
+import "encoding/json"
+
+// Типы именованных сущностей, которые Алиса выделяет в запросе.
+const (
+	EntityFIO      = "YANDEX.FIO"
+	EntityNumber   = "YANDEX.NUMBER"
+	EntityDateTime = "YANDEX.DATETIME"
+	EntityGeo      = "YANDEX.GEO"
+)
+
+// Встроенные интенты Алисы. Их нужно включить в настройках навыка.
+const (
+	IntentConfirm = "YANDEX.CONFIRM"
+	IntentReject  = "YANDEX.REJECT"
+)
+
+// NLU — результат разбора запроса пользователя на стороне Алисы.
+type NLU struct {
+	Tokens   []string          `json:"tokens"`
+	Entities []Entity          `json:"entities"`
+	Intents  map[string]Intent `json:"intents,omitempty"`
+}
+
+// TokensRange — положение сущности в списке токенов, End не включается.
+type TokensRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Entity — именованная сущность. Формат Value зависит от Type,
+// для разбора используйте методы FIO, Number, DateTime и Geo.
+type Entity struct {
+	Type   string          `json:"type"`
+	Tokens TokensRange     `json:"tokens"`
+	Value  json.RawMessage `json:"value"`
+}
+
+// FIO — значение сущности YANDEX.FIO. Части имени приходят в именительном падеже.
+type FIO struct {
+	FirstName      string `json:"first_name,omitempty"`
+	PatronymicName string `json:"patronymic_name,omitempty"`
+	LastName       string `json:"last_name,omitempty"`
+}
+
+// DateTime — значение сущности YANDEX.DATETIME.
+// Флаги *IsRelative означают, что значение задано относительно текущего момента.
+type DateTime struct {
+	Year             *int `json:"year,omitempty"`
+	YearIsRelative   bool `json:"year_is_relative,omitempty"`
+	Month            *int `json:"month,omitempty"`
+	MonthIsRelative  bool `json:"month_is_relative,omitempty"`
+	Day              *int `json:"day,omitempty"`
+	DayIsRelative    bool `json:"day_is_relative,omitempty"`
+	Hour             *int `json:"hour,omitempty"`
+	HourIsRelative   bool `json:"hour_is_relative,omitempty"`
+	Minute           *int `json:"minute,omitempty"`
+	MinuteIsRelative bool `json:"minute_is_relative,omitempty"`
+}
+
+// Geo — значение сущности YANDEX.GEO.
+type Geo struct {
+	Country     string `json:"country,omitempty"`
+	City        string `json:"city,omitempty"`
+	Street      string `json:"street,omitempty"`
+	HouseNumber string `json:"house_number,omitempty"`
+	Airport     string `json:"airport,omitempty"`
+}
+
+// Intent — интент, распознанный Алисой, со значениями слотов.
+type Intent struct {
+	Slots map[string]Slot `json:"slots"`
+}
+
+// Slot — значение слота интента.
+type Slot struct {
+	Type   string          `json:"type"`
+	Tokens TokensRange     `json:"tokens"`
+	Value  json.RawMessage `json:"value"`
+}
+
+// FIO возвращает значение сущности YANDEX.FIO.
+func (e Entity) FIO() (FIO, bool) {
+	var v FIO
+	ok := e.Type == EntityFIO && json.Unmarshal(e.Value, &v) == nil
+	return v, ok
+}
+
+// Number возвращает значение сущности YANDEX.NUMBER.
+func (e Entity) Number() (float64, bool) {
+	var v float64
+	ok := e.Type == EntityNumber && json.Unmarshal(e.Value, &v) == nil
+	return v, ok
+}
+
+// DateTime возвращает значение сущности YANDEX.DATETIME.
+func (e Entity) DateTime() (DateTime, bool) {
+	var v DateTime
+	ok := e.Type == EntityDateTime && json.Unmarshal(e.Value, &v) == nil
+	return v, ok
+}
+
+// Geo возвращает значение сущности YANDEX.GEO.
+func (e Entity) Geo() (Geo, bool) {
+	var v Geo
+	ok := e.Type == EntityGeo && json.Unmarshal(e.Value, &v) == nil
+	return v, ok
+}
+
+// Entity возвращает первую сущность заданного типа.
+func (n NLU) Entity(entityType string) (Entity, bool) {
+	for _, e := range n.Entities {
+		if e.Type == entityType {
+			return e, true
+		}
+	}
+
+	return Entity{}, false
+}
+
+// HasIntent сообщает, распознала ли Алиса интент с заданным именем.
+func (n NLU) HasIntent(name string) bool {
+	_, ok := n.Intents[name]
+	return ok
+}
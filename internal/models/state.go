@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// State — состояние навыка, которое Алиса хранит на своей стороне
+// и присылает в каждом запросе.
+type State struct {
+	Session     SessionState     `json:"session"`
+	User        UserState        `json:"user"`
+	Application ApplicationState `json:"application"`
+}
+
+// SessionState — состояние текущей сессии. Живёт до конца сессии
+// и перезаписывается каждым ответом навыка, поэтому его нужно возвращать целиком.
+type SessionState struct {
+	// Compose — черновик сообщения, которое пользователь составляет по шагам.
+	Compose ComposeState `json:"compose"`
+	// LastRead — последнее прочитанное сообщение, на него отвечает команда «Ответь».
+	LastRead LastReadState `json:"last_read"`
+	// Inbox — страница списка входящих, которую пользователь слушает.
+	Inbox InboxState `json:"inbox"`
+	// ClearInbox — навык спросил, удалить ли все сообщения, и ждёт ответа.
+	// Вопрос относится только к следующей реплике пользователя.
+	ClearInbox bool `json:"clear_inbox,omitempty"`
+}
+
+// InboxState — положение в списке входящих для команд «Дальше» и «Назад».
+type InboxState struct {
+	// Page — номер страницы, начиная с 1. Ноль — список ещё не открывали.
+	Page int `json:"page,omitempty"`
+	// IDs — сообщения страницы в том порядке, в котором навык их пронумеровал.
+	// По ним «Прочитай второе» и «Удали второе» находят названное сообщение,
+	// а первое и последнее служат курсорами для «Назад» и «Дальше».
+	IDs []int64 `json:"ids,omitempty"`
+}
+
+// IsZero сообщает, что список входящих не открывали.
+func (s InboxState) IsZero() bool {
+	return s.Page == 0 && len(s.IDs) == 0
+}
+
+// LastReadState — сообщение, которое пользователь прочитал последним.
+type LastReadState struct {
+	MessageID int64 `json:"message_id,omitempty"`
+	// Sender — имя отправителя.
+	Sender string `json:"sender,omitempty"`
+}
+
+// ComposeState — состояние диалога составления сообщения и заполненные слоты.
+type ComposeState struct {
+	// Step — шаг диалога, см. состояния в cmd/skill/compose.go.
+	Step string `json:"step,omitempty"`
+	// Recipient — идентификатор получателя.
+	Recipient string `json:"recipient,omitempty"`
+	// RecipientName — имя получателя в том виде, в котором его произнесли.
+	RecipientName string `json:"recipient_name,omitempty"`
+	// Text — текст сообщения.
+	Text string `json:"text,omitempty"`
+	// ReplyTo — идентификатор сообщения, на которое пользователь отвечает.
+	ReplyTo int64 `json:"reply_to,omitempty"`
+	// ExpiresAt — unix-время, после которого черновик не отправляется
+	// и не перехватывает команды пользователя.
+	ExpiresAt int64 `json:"expires_at,omitempty"`
+}
+
+// Expired сообщает, что черновик не дождался ответа пользователя вовремя.
+func (s ComposeState) Expired(now time.Time) bool {
+	return s.ExpiresAt != 0 && now.Unix() > s.ExpiresAt
+}
+
+// IsZero сообщает, что состояние сессии пустое.
+func (s SessionState) IsZero() bool {
+	return s.Compose == ComposeState{} && s.LastRead == LastReadState{} && s.Inbox.IsZero() && !s.ClearInbox
+}
+
+// UserState — состояние пользователя, авторизованного в Яндексе.
+// Сохраняется между сессиями и на всех его устройствах.
+type UserState struct {
+	Username string `json:"username,omitempty"`
+}
+
+// ApplicationState — состояние экземпляра приложения, в котором запущен навык.
+// Заменяет UserState для неавторизованных пользователей.
+type ApplicationState struct {
+	Username string `json:"username,omitempty"`
+}
+
+// IsZero сообщает, что состояние приложения пустое.
+func (s ApplicationState) IsZero() bool {
+	return s == ApplicationState{}
+}
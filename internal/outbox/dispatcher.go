@@ -0,0 +1,229 @@
+// Package outbox доставляет события из store.Outbox внешним системам.
+//
+// Доставка «хотя бы один раз»: событие удаляется из очереди только после того,
+// как его приняли все получатели, поэтому после сбоя получатель может увидеть
+// событие повторно и должен отличать повторы по Event.ID. Недоставленное
+// событие откладывается и не задерживает следующие, поэтому порядок
+// событий у получателя может отличаться от порядка создания.
+package outbox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/logger"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"go.uber.org/zap"
+)
+
+// Sink — получатель событий.
+type Sink interface {
+	Publish(ctx context.Context, e store.Event) error
+}
+
+const (
+	// batchSize — сколько событий забирать из очереди за раз.
+	batchSize = 100
+	// lease — на сколько забранные события скрыты от других экземпляров навыка.
+	// Если экземпляр упадёт, не доставив их, события заберут снова.
+	lease = time.Minute
+	// leaseMargin — за сколько до конца аренды пакет перестаёт доставлять события,
+	// чтобы успеть подтвердить последнее, пока его не забрал другой экземпляр.
+	leaseMargin = 5 * time.Second
+	// maxAttempts — после стольких попыток событие выводится из очереди.
+	maxAttempts = 10
+	// retryDelay, maxRetryDelay — пауза перед повторной доставкой удваивается
+	// с каждой попыткой, но не превышает maxRetryDelay.
+	retryDelay    = time.Second
+	maxRetryDelay = 5 * time.Minute
+)
+
+// Dispatcher периодически забирает события из очереди и передаёт их получателям.
+// Dispatch не вызывают из нескольких горутин одновременно: для параллельной
+// доставки запускают несколько экземпляров навыка.
+type Dispatcher struct {
+	source   store.Outbox
+	sinks    []Sink
+	interval time.Duration
+	// retryDelay — пауза перед первой повторной доставкой
+	retryDelay time.Duration
+	// lease — на сколько забирать события, см. одноимённую константу
+	lease time.Duration
+
+	// published — каким получателям уже передано событие, которое ещё не
+	// приняли остальные: при повторе ему не нужно получать событие снова
+	published map[int64]*delivery
+}
+
+// delivery — получатели, которые приняли событие, и когда это событие
+// последний раз забирал этот экземпляр навыка.
+type delivery struct {
+	sinks     map[int]bool
+	claimedAt time.Time
+}
+
+func NewDispatcher(source store.Outbox, interval time.Duration, sinks ...Sink) *Dispatcher {
+	return &Dispatcher{
+		source:     source,
+		sinks:      sinks,
+		interval:   interval,
+		retryDelay: retryDelay,
+		lease:      lease,
+		published:  make(map[int64]*delivery),
+	}
+}
+
+// Run доставляет события, пока не отменён ctx.
+// Ошибки доставки не останавливают работу: событие будет отправлено повторно.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := d.Dispatch(ctx); err != nil && ctx.Err() == nil {
+			logger.Log.Warn("cannot dispatch outbox events", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Dispatch доставляет накопившиеся события и возвращает число доставленных.
+// Событие, которое не принял получатель, откладывается с растущей паузой,
+// а после maxAttempts попыток выводится из очереди. Ошибки доставки
+// возвращаются вместе, после обработки всех событий.
+//
+// Пакет доставляется, пока не кончилась его аренда: медленные получатели
+// не дают пакету пережить её, иначе те же события заберёт и разошлёт другой
+// экземпляр навыка. Недоставленные события пакета заберут снова, когда аренда истечёт.
+func (d *Dispatcher) Dispatch(ctx context.Context) (int, error) {
+	d.forget(time.Now())
+
+	delivered := 0
+	var errs []error
+	for {
+		claimedAt := time.Now()
+		events, err := d.source.ClaimEvents(ctx, batchSize, d.lease)
+		if err != nil {
+			return delivered, errors.Join(append(errs, fmt.Errorf("cannot claim events: %w", err))...)
+		}
+
+		batchCtx, cancel := context.WithDeadline(ctx, claimedAt.Add(d.lease-leaseMargin))
+		n, err := d.dispatchBatch(batchCtx, ctx, claimedAt, events)
+		expired := errors.Is(batchCtx.Err(), context.DeadlineExceeded)
+		cancel()
+		delivered += n
+		errs = append(errs, err)
+		if expired && ctx.Err() == nil {
+			// остаток пакета доставят, когда аренда истечёт
+			return delivered, errors.Join(append(errs, errors.New("lease expired before all claimed events were published"))...)
+		}
+		if ctx.Err() != nil || len(events) < batchSize {
+			return delivered, errors.Join(errs...)
+		}
+	}
+}
+
+// dispatchBatch доставляет забранные события, пока не отменён batchCtx.
+// Очередь обновляется в ctx: подтвердить уже доставленное событие нужно и после конца аренды.
+func (d *Dispatcher) dispatchBatch(batchCtx, ctx context.Context, claimedAt time.Time, events []store.Event) (int, error) {
+	delivered := 0
+	var errs []error
+	for _, e := range events {
+		if batchCtx.Err() != nil {
+			break
+		}
+
+		if d.published[e.ID] == nil {
+			d.published[e.ID] = &delivery{sinks: make(map[int]bool)}
+		}
+		d.published[e.ID].claimedAt = claimedAt
+
+		if err := d.publish(batchCtx, e); err != nil {
+			// событие не доставлено из-за конца аренды, а не по вине получателя
+			if batchCtx.Err() != nil {
+				break
+			}
+
+			errs = append(errs, fmt.Errorf("cannot publish event %d: %w", e.ID, err))
+			if err := d.postpone(ctx, e); err != nil {
+				return delivered, errors.Join(append(errs, err)...)
+			}
+			continue
+		}
+
+		delete(d.published, e.ID)
+		if err := d.source.AckEvent(ctx, e.ID); err != nil {
+			return delivered, errors.Join(append(errs, fmt.Errorf("cannot ack event %d: %w", e.ID, err))...)
+		}
+		delivered++
+	}
+
+	return delivered, errors.Join(errs...)
+}
+
+// publish передаёт событие получателям, которые его ещё не приняли.
+func (d *Dispatcher) publish(ctx context.Context, e store.Event) error {
+	accepted := d.published[e.ID].sinks
+	for i, sink := range d.sinks {
+		if accepted[i] {
+			continue
+		}
+		if err := sink.Publish(ctx, e); err != nil {
+			return err
+		}
+		accepted[i] = true
+	}
+
+	return nil
+}
+
+// forget забывает о событиях, которые этот экземпляр давно не забирал:
+// их доставил или вывел из очереди другой экземпляр, или не удалось
+// подтвердить доставку. Своё отложенное событие экземпляр забирает снова
+// не позже чем через maxRetryDelay после конца аренды.
+func (d *Dispatcher) forget(now time.Time) {
+	for id, p := range d.published {
+		if now.Sub(p.claimedAt) > d.lease+maxRetryDelay {
+			delete(d.published, id)
+		}
+	}
+}
+
+// postpone откладывает недоставленное событие или, если попытки кончились,
+// выводит его из очереди.
+func (d *Dispatcher) postpone(ctx context.Context, e store.Event) error {
+	if e.Attempts >= maxAttempts {
+		delete(d.published, e.ID)
+		logger.Log.Error("giving up on outbox event", zap.Int64("id", e.ID), zap.Int("attempts", e.Attempts))
+
+		if err := d.source.FailEvent(ctx, e.ID); err != nil {
+			return fmt.Errorf("cannot fail event %d: %w", e.ID, err)
+		}
+		return nil
+	}
+
+	if err := d.source.RetryEvent(ctx, e.ID, time.Now().Add(d.backoff(e.Attempts))); err != nil {
+		return fmt.Errorf("cannot postpone event %d: %w", e.ID, err)
+	}
+	return nil
+}
+
+// backoff возвращает паузу перед следующей попыткой после attempts неудачных.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.retryDelay
+	for i := 1; i < attempts && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		return maxRetryDelay
+	}
+
+	return delay
+}
@@ -0,0 +1,191 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store/memory"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// failingSink отклоняет первые failures событий и считает принятые.
+type failingSink struct {
+	failures  int
+	published int
+}
+
+func (s *failingSink) Publish(ctx context.Context, e store.Event) error {
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("sink is down")
+	}
+
+	s.published++
+	return nil
+}
+
+func TestDispatch(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStore()
+	for i := 0; i < 3; i++ {
+		require.NoError(t, s.SaveMessage(ctx, "masha-id", store.Message{Sender: "petya-id", Payload: "Привет"}))
+	}
+
+	events := make(chan store.Event, 10)
+	flaky := &failingSink{failures: 1}
+	d := NewDispatcher(s, 0, ChannelSink(events), flaky, LogSink{Logger: zap.NewNop()})
+
+	// первое событие не принял второй получатель, остальные это не задержало
+	n, err := d.Dispatch(ctx)
+	assert.Error(t, err)
+	assert.Equal(t, 2, n)
+
+	// недоставленное событие отложено и сразу не повторяется
+	n, err = d.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	require.NoError(t, s.RetryEvent(ctx, 1, time.Now()))
+	n, err = d.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	pending, err := s.ClaimEvents(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, pending)
+
+	// повтор получили только те, кто не принял событие в первый раз
+	assert.Len(t, events, 3)
+	assert.Equal(t, 3, flaky.published)
+
+	var saved store.MessageSaved
+	first := <-events
+	require.NoError(t, json.Unmarshal(first.Payload, &saved))
+	assert.Equal(t, int64(1), first.ID)
+	assert.Equal(t, "masha-id", saved.Recipient)
+	assert.Equal(t, "Привет", saved.Text)
+}
+
+func TestDispatchGivesUp(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStore()
+	require.NoError(t, s.SaveMessage(ctx, "masha-id", store.Message{Sender: "petya-id", Payload: "Привет"}))
+
+	down := &failingSink{failures: maxAttempts + 1}
+	d := NewDispatcher(s, 0, down)
+	d.retryDelay = 0
+
+	for i := 0; i < maxAttempts; i++ {
+		_, err := d.Dispatch(ctx)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, 1, down.failures)
+
+	// событие выведено из очереди и больше не доставляется
+	n, err := d.Dispatch(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, 1, down.failures)
+	assert.Empty(t, d.published)
+}
+
+// slowSink принимает события, пока не отменят контекст доставки.
+type slowSink struct {
+	calls int
+}
+
+func (s *slowSink) Publish(ctx context.Context, e store.Event) error {
+	s.calls++
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestDispatchLeaseExpires(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStore()
+	for i := 0; i < 2; i++ {
+		require.NoError(t, s.SaveMessage(ctx, "masha-id", store.Message{Sender: "petya-id", Payload: "Привет"}))
+	}
+
+	slow := &slowSink{}
+	d := NewDispatcher(s, 0, slow)
+	d.lease = leaseMargin + 50*time.Millisecond
+
+	// медленный получатель не задержал пакет дольше аренды,
+	// а второе событие пакета после конца аренды не отправлялось
+	n, err := d.Dispatch(ctx)
+	assert.Error(t, err)
+	assert.Zero(t, n)
+	assert.Equal(t, 1, slow.calls)
+}
+
+func TestDispatchForgets(t *testing.T) {
+	ctx := context.Background()
+	s := memory.NewStore()
+	require.NoError(t, s.SaveMessage(ctx, "masha-id", store.Message{Sender: "petya-id", Payload: "Привет"}))
+
+	events := make(chan store.Event, 10)
+	d := NewDispatcher(s, 0, ChannelSink(events), &failingSink{failures: 1})
+
+	_, err := d.Dispatch(ctx)
+	assert.Error(t, err)
+	require.Contains(t, d.published, int64(1))
+
+	// событие доставил и подтвердил другой экземпляр навыка
+	require.NoError(t, s.RetryEvent(ctx, 1, time.Now()))
+	claimed, err := s.ClaimEvents(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, claimed, 1)
+	require.NoError(t, s.AckEvent(ctx, claimed[0].ID))
+
+	d.forget(time.Now())
+	assert.Contains(t, d.published, int64(1))
+
+	d.forget(time.Now().Add(lease + maxRetryDelay + time.Second))
+	assert.Empty(t, d.published)
+}
+
+func TestBackoff(t *testing.T) {
+	d := NewDispatcher(nil, 0)
+
+	assert.Equal(t, time.Second, d.backoff(1))
+	assert.Equal(t, 2*time.Second, d.backoff(2))
+	assert.Equal(t, 8*time.Second, d.backoff(4))
+	assert.Equal(t, maxRetryDelay, d.backoff(maxAttempts))
+	assert.Equal(t, maxRetryDelay, d.backoff(100))
+}
+
+func TestWebhookSink(t *testing.T) {
+	var got store.Event
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, store.EventMessageSaved, r.Header.Get("X-Event-Type"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(body, &got))
+
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	sink := WebhookSink{URL: srv.URL}
+	e := store.Event{ID: 7, Type: store.EventMessageSaved, Payload: json.RawMessage(`{"message_id":1}`)}
+
+	require.NoError(t, sink.Publish(context.Background(), e))
+	assert.Equal(t, int64(7), got.ID)
+	assert.JSONEq(t, `{"message_id":1}`, string(got.Payload))
+
+	status = http.StatusInternalServerError
+	assert.Error(t, sink.Publish(context.Background(), e))
+}
@@ -0,0 +1,78 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"go.uber.org/zap"
+)
+
+// WebhookSink отправляет событие POST-запросом с телом в JSON.
+// Любой ответ, кроме 2xx, считается ошибкой, и событие будет отправлено повторно.
+type WebhookSink struct {
+	URL    string
+	Client *http.Client
+}
+
+func (s WebhookSink) Publish(ctx context.Context, e store.Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Event-Type", e.Type)
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// LogSink пишет события в лог.
+type LogSink struct {
+	Logger *zap.Logger
+}
+
+func (s LogSink) Publish(ctx context.Context, e store.Event) error {
+	s.Logger.Info("outbox event",
+		zap.Int64("id", e.ID),
+		zap.String("type", e.Type),
+		zap.ByteString("payload", e.Payload),
+	)
+
+	return nil
+}
+
+// ChannelSink передаёт события в канал, например другой части навыка или тесту.
+// Publish ждёт, пока событие заберут из канала.
+type ChannelSink chan<- store.Event
+
+func (s ChannelSink) Publish(ctx context.Context, e store.Event) error {
+	select {
+	case s <- e:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,309 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Command — команда навыка, которую пользователь произносит на своём языке.
+type Command int
+
+const (
+	CommandSend Command = iota
+	CommandRead
+	CommandThread
+	CommandReply
+	CommandDelete
+	CommandClear
+	CommandRegister
+	CommandInbox
+	CommandNext
+	CommandBack
+	CommandExit
+)
+
+// Lexicon — слова одного языка, по которым навык понимает команды.
+// Все слова записаны в нижнем регистре: так команды присылает Алиса.
+type Lexicon struct {
+	// commands — фразы, которыми начинается команда: «прочитай», «покажи сообщения».
+	commands map[Command][]string
+	// final — команды, которые в языке обычно произносят с глаголом в конце:
+	// «ikinci mesajı oku». Их фразы ищутся и в конце команды.
+	final map[Command]bool
+
+	// служебные слова, которые могут стоять между глаголом, именем и текстом
+	messageWords    map[string]bool
+	registerFillers map[string]bool
+
+	// numbers — порядковые и количественные числительные до десяти
+	numbers map[string]int
+	// numberSuffixes — окончания, с которыми произносят номер цифрами: «2-е», «3rd»
+	numberSuffixes []string
+	lastWords      map[string]bool
+	allWords       map[string]bool
+	// readPrefixes — начала слов «прочитанные»: «удали все прочитанные»
+	readPrefixes []string
+
+	confirmWords  map[string]bool
+	rejectWords   map[string]bool
+	cancelPhrases map[string]bool
+
+	// names возвращает возможные начальные формы имени получателя,
+	// начиная с наиболее вероятной.
+	names func(name string) []string
+}
+
+// For возвращает словарь команд для языка locale («en-US», «tr-TR»).
+// Для неизвестных языков, как и фразы навыка, команды понимаются по-русски.
+func For(locale string) *Lexicon {
+	lang, _, _ := strings.Cut(locale, "-")
+	switch strings.ToLower(lang) {
+	case "en":
+		return English
+	case "tr":
+		return Turkish
+	case "kk":
+		return Kazakh
+	}
+
+	return Russian
+}
+
+// Is сообщает, что команда command — это команда c:
+// «пока, Алиса» — CommandExit, а «покажи сообщения» — нет.
+// Фразы сравниваются целыми словами.
+func (l *Lexicon) Is(command string, c Command) bool {
+	_, ok := l.cut(strings.Fields(command), c)
+	return ok
+}
+
+// strip отрезает от команды фразу команды c, если команда с неё начинается
+// или, для команд с глаголом в конце, ею заканчивается.
+func (l *Lexicon) strip(command string, c Command) string {
+	fields, ok := l.cut(strings.Fields(command), c)
+	if !ok {
+		return strings.TrimSpace(command)
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// cut отбрасывает от слов команды фразу команды c.
+func (l *Lexicon) cut(fields []string, c Command) ([]string, bool) {
+	for _, phrase := range l.commands[c] {
+		words := strings.Fields(phrase)
+		if len(fields) < len(words) {
+			continue
+		}
+		if sameWords(fields[:len(words)], words) {
+			return fields[len(words):], true
+		}
+		if l.final[c] && sameWords(fields[len(fields)-len(words):], words) {
+			return fields[:len(fields)-len(words)], true
+		}
+	}
+
+	return fields, false
+}
+
+func sameWords(fields, words []string) bool {
+	for i, w := range words {
+		if strings.ToLower(trimPunct(fields[i])) != w {
+			return false
+		}
+	}
+
+	return true
+}
+
+// number распознаёт номер сообщения: «второе», «два», «2-е».
+func (l *Lexicon) number(word string) (int, bool) {
+	if n, ok := l.numbers[word]; ok {
+		return n, true
+	}
+
+	digits := word
+	for _, suffix := range l.numberSuffixes {
+		if strings.HasSuffix(word, suffix) {
+			digits = strings.TrimSuffix(word, suffix)
+			break
+		}
+	}
+
+	n, err := strconv.Atoi(digits)
+	return n, err == nil && n > 0
+}
+
+func set(words ...string) map[string]bool {
+	m := make(map[string]bool, len(words))
+	for _, w := range words {
+		m[w] = true
+	}
+
+	return m
+}
+
+// Russian — команды на русском языке.
+var Russian = &Lexicon{
+	commands: map[Command][]string{
+		CommandSend:     {"отправь", "отправить", "передай", "напиши"},
+		CommandRead:     {"прочитай", "прочти", "прочитать"},
+		CommandThread:   {"прочитай переписку", "прочти переписку"},
+		CommandReply:    {"ответь", "ответить"},
+		CommandDelete:   {"удали", "удалить"},
+		CommandClear:    {"очисти", "очистить"},
+		CommandRegister: {"зарегистрируй", "зарегистрировать"},
+		CommandInbox:    {"покажи сообщения", "список сообщений", "входящие"},
+		CommandNext:     {"дальше"},
+		CommandBack:     {"назад"},
+		CommandExit:     {"хватит", "выход", "стоп", "пока"},
+	},
+
+	messageWords:    set("сообщение", "сообщения", "текст", "что"),
+	registerFillers: set("меня", "как", "под", "имя", "именем", "с", "ником"),
+
+	numbers: map[string]int{
+		"первое": 1, "второе": 2, "третье": 3, "четвёртое": 4, "четвертое": 4,
+		"пятое": 5, "шестое": 6, "седьмое": 7, "восьмое": 8, "девятое": 9, "десятое": 10,
+		"один": 1, "одно": 1, "два": 2, "три": 3, "четыре": 4, "пять": 5,
+		"шесть": 6, "семь": 7, "восемь": 8, "девять": 9, "десять": 10,
+	},
+	numberSuffixes: []string{"-ое", "-е"},
+	lastWords:      set("последнее", "крайнее"),
+	allWords:       set("все", "всё"),
+	readPrefixes:   []string{"прочитанн"},
+
+	confirmWords:  set("да", "ага", "конечно", "верно", "отправляй"),
+	rejectWords:   set("нет", "не", "неверно", "отмена", "отмени"),
+	cancelPhrases: set("отмена", "отмени", "отменить", "не надо", "не отправляй"),
+
+	names: Nominative,
+}
+
+// English — команды на английском языке.
+var English = &Lexicon{
+	commands: map[Command][]string{
+		CommandSend:     {"send", "tell", "write", "text"},
+		CommandRead:     {"read"},
+		CommandThread:   {"read conversation", "read the conversation", "read thread", "read the thread"},
+		CommandReply:    {"reply", "answer"},
+		CommandDelete:   {"delete", "remove"},
+		CommandClear:    {"clear", "empty"},
+		CommandRegister: {"register", "sign me up"},
+		CommandInbox:    {"show messages", "show my messages", "list messages", "check messages", "my messages", "inbox"},
+		CommandNext:     {"next", "more"},
+		CommandBack:     {"back", "previous"},
+		CommandExit:     {"stop", "exit", "quit", "bye", "goodbye"},
+	},
+
+	messageWords:    set("message", "a", "the", "to", "text", "that", "saying"),
+	registerFillers: set("me", "as", "under", "the", "name", "with", "nickname", "username", "called"),
+
+	numbers: map[string]int{
+		"first": 1, "second": 2, "third": 3, "fourth": 4, "fifth": 5,
+		"sixth": 6, "seventh": 7, "eighth": 8, "ninth": 9, "tenth": 10,
+		"one": 1, "two": 2, "three": 3, "four": 4, "five": 5,
+		"six": 6, "seven": 7, "eight": 8, "nine": 9, "ten": 10,
+	},
+	numberSuffixes: []string{"st", "nd", "rd", "th"},
+	lastWords:      set("last", "latest"),
+	allWords:       set("all", "everything"),
+	readPrefixes:   []string{"read"},
+
+	confirmWords:  set("yes", "yeah", "yep", "sure", "ok", "okay"),
+	rejectWords:   set("no", "nope", "cancel", "don't"),
+	cancelPhrases: set("cancel", "never mind", "forget it", "don't send"),
+
+	names: asSpoken,
+}
+
+// Turkish — команды на турецком языке. Глагол обычно стоит в конце фразы:
+// «Maşa'ya merhaba gönder».
+var Turkish = &Lexicon{
+	commands: map[Command][]string{
+		CommandSend:     {"gönder", "yolla", "ilet", "yaz"},
+		CommandRead:     {"oku"},
+		CommandThread:   {"yazışmayı oku", "konuşmayı oku"},
+		CommandReply:    {"yanıtla", "cevapla", "cevap ver"},
+		CommandDelete:   {"sil"},
+		CommandClear:    {"temizle", "boşalt"},
+		CommandRegister: {"kaydet", "kaydol", "kayıt ol"},
+		CommandInbox:    {"mesajları göster", "mesajları listele", "mesajlarım", "gelen kutusu"},
+		CommandNext:     {"sonraki", "ileri", "devam"},
+		CommandBack:     {"geri", "önceki"},
+		CommandExit:     {"çıkış", "hoşça kal", "görüşürüz", "güle güle"},
+	},
+	final: verbFinal,
+
+	messageWords:    set("mesaj", "mesajı", "bir"),
+	registerFillers: set("beni", "adıyla", "ismiyle", "adı", "ile", "olarak", "kullanıcı"),
+
+	numbers: map[string]int{
+		"birinci": 1, "ikinci": 2, "üçüncü": 3, "dördüncü": 4, "beşinci": 5,
+		"altıncı": 6, "yedinci": 7, "sekizinci": 8, "dokuzuncu": 9, "onuncu": 10,
+		"bir": 1, "iki": 2, "üç": 3, "dört": 4, "beş": 5,
+		"altı": 6, "yedi": 7, "sekiz": 8, "dokuz": 9, "on": 10,
+	},
+	lastWords:    set("son", "sonuncu"),
+	allWords:     set("tüm", "tümünü", "bütün", "hepsini"),
+	readPrefixes: []string{"okun"},
+
+	confirmWords:  set("evet", "tamam", "olur"),
+	rejectWords:   set("hayır", "yok", "iptal", "vazgeç"),
+	cancelPhrases: set("iptal", "vazgeç", "boşver", "gönderme"),
+
+	names: turkishNominative,
+}
+
+// Kazakh — команды на казахском языке. Глагол обычно стоит в конце фразы:
+// «Маратқа сәлем жібер».
+var Kazakh = &Lexicon{
+	commands: map[Command][]string{
+		CommandSend:     {"жібер", "жолда"},
+		CommandRead:     {"оқы"},
+		CommandThread:   {"хат алмасуды оқы", "әңгімені оқы"},
+		CommandReply:    {"жауап бер", "жауап қайтар"},
+		CommandDelete:   {"жой", "өшір"},
+		CommandClear:    {"тазала"},
+		CommandRegister: {"тірке", "тіркел"},
+		CommandInbox:    {"хабарламаларды көрсет", "хабарламаларым", "кіріс хаттар"},
+		CommandNext:     {"әрі қарай", "келесі"},
+		CommandBack:     {"артқа", "алдыңғы"},
+		CommandExit:     {"шығу", "сау бол", "қош бол"},
+	},
+	final: verbFinal,
+
+	messageWords:    set("хабарлама", "хабарламаны", "хат"),
+	registerFillers: set("мені", "атымен", "есіммен", "деп", "аты", "атты"),
+
+	numbers: map[string]int{
+		"бірінші": 1, "екінші": 2, "үшінші": 3, "төртінші": 4, "бесінші": 5,
+		"алтыншы": 6, "жетінші": 7, "сегізінші": 8, "тоғызыншы": 9, "оныншы": 10,
+		"бір": 1, "екі": 2, "үш": 3, "төрт": 4, "бес": 5,
+		"алты": 6, "жеті": 7, "сегіз": 8, "тоғыз": 9, "он": 10,
+	},
+	numberSuffixes: []string{"-ші", "-шы"},
+	lastWords:      set("соңғы"),
+	allWords:       set("барлық", "барлығын", "бәрін"),
+	readPrefixes:   []string{"оқылған"},
+
+	confirmWords:  set("иә", "ия", "жарайды", "әрине"),
+	rejectWords:   set("жоқ", "болдырма", "тоқтат"),
+	cancelPhrases: set("болдырма", "керек емес", "жіберме"),
+
+	names: kazakhNominative,
+}
+
+// verbFinal — команды, которые в турецком и казахском заканчиваются глаголом.
+// Прощание и листание произносят отдельным словом, поэтому их ищем только в начале:
+// «...сау бол» в конце диктуемого сообщения не завершает сессию.
+var verbFinal = map[Command]bool{
+	CommandSend:     true,
+	CommandRead:     true,
+	CommandThread:   true,
+	CommandReply:    true,
+	CommandDelete:   true,
+	CommandClear:    true,
+	CommandRegister: true,
+	CommandInbox:    true,
+}
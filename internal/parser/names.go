@@ -0,0 +1,176 @@
+package parser
+
+import "strings"
+
+// dativeEndings — окончания дательного падежа и соответствующие окончания
+// именительного. Для одного окончания вариантов может быть несколько,
+// первым идёт наиболее распространённый.
+var dativeEndings = []struct {
+	dative      string
+	nominatives []string
+}{
+	{"ии", []string{"ия"}},    // Марии → Мария
+	{"ье", []string{"ья"}},    // Илье → Илья
+	{"ю", []string{"ь", "й"}}, // Игорю → Игорь, Андрею → Андрей
+	{"у", []string{""}},       // Ивану → Иван
+	{"е", []string{"а", "я"}}, // Маше → Маша, Вере → Вера, Пете → Петя
+	{"и", []string{"ь"}},      // Любови → Любовь
+}
+
+// softStems — согласные, после которых в дательном «-е» чаще стоит
+// уменьшительное имя на «-я»: Пете → Петя, Феде → Федя, Коле → Коля,
+// Ване → Ваня, Васе → Вася. Удвоенная согласная оставляет «-а»: Анне → Анна.
+const softStems = "тдлнс"
+
+// Nominative возвращает возможные начальные формы имени, произнесённого
+// в дательном падеже («Маше» → «Маша»). Первым элементом всегда идёт
+// наиболее вероятная форма, последним — исходное слово: имя могли
+// и не склонять.
+func Nominative(name string) []string {
+	if name == "" {
+		return nil
+	}
+
+	runes := []rune(name)
+	lower := strings.ToLower(name)
+
+	var candidates []string
+	for _, e := range dativeEndings {
+		if !strings.HasSuffix(lower, e.dative) {
+			continue
+		}
+
+		stem := string(runes[:len(runes)-len([]rune(e.dative))])
+		if stem == "" {
+			continue
+		}
+		for _, n := range prefer(stem, e.nominatives) {
+			if !spellable(stem, n) {
+				continue
+			}
+			candidates = appendUnique(candidates, stem+matchCase(n, runes))
+		}
+		break
+	}
+
+	return appendUnique(candidates, name)
+}
+
+// prefer упорядочивает окончания именительного падежа по вероятности для основы stem.
+func prefer(stem string, nominatives []string) []string {
+	if len(nominatives) != 2 || nominatives[0] != "а" || nominatives[1] != "я" {
+		return nominatives
+	}
+
+	r := []rune(strings.ToLower(stem))
+	last := r[len(r)-1]
+	doubled := len(r) > 1 && r[len(r)-2] == last
+	if strings.ContainsRune(softStems, last) && !doubled {
+		return []string{"я", "а"}
+	}
+
+	return nominatives
+}
+
+const (
+	vowels   = "аеёиоуыэюя"
+	sibilant = "жшчщц"
+)
+
+// spellable отбрасывает окончания, невозможные после последней буквы основы:
+// «я» и «ь» не пишутся после шипящих, «а» и «ь» — после гласных.
+func spellable(stem, ending string) bool {
+	if ending == "" {
+		return true
+	}
+
+	stemRunes := []rune(strings.ToLower(stem))
+	last := string(stemRunes[len(stemRunes)-1])
+	first := string([]rune(ending)[0])
+
+	switch {
+	case strings.Contains(sibilant, last):
+		return first != "я" && first != "ь"
+	case strings.Contains(vowels, last):
+		return first != "а" && first != "ь"
+	}
+
+	return true
+}
+
+// matchCase приводит окончание к регистру исходного слова:
+// Алиса присылает команды в нижнем регистре, но имя могли передать и заглавными.
+func matchCase(ending string, word []rune) string {
+	if len(word) > 1 && strings.ToUpper(string(word)) == string(word) {
+		return strings.ToUpper(ending)
+	}
+
+	return ending
+}
+
+func appendUnique(list []string, s string) []string {
+	for _, v := range list {
+		if v == s {
+			return list
+		}
+	}
+
+	return append(list, s)
+}
+
+// asSpoken оставляет имя как есть: в английском имена не склоняются.
+func asSpoken(name string) []string {
+	if name == "" {
+		return nil
+	}
+
+	return []string{name}
+}
+
+// turkishDatives — окончания дательного падежа в турецком:
+// Ayşe'ye → Ayşe, Ahmet'e → Ahmet, Maşaya → Maşa.
+var turkishDatives = []string{"ya", "ye", "a", "e"}
+
+// turkishNominative возвращает возможные начальные формы имени, произнесённого
+// в дательном падеже. На письме окончание отделяют апострофом, но Алиса
+// присылает команду без знаков препинания.
+func turkishNominative(name string) []string {
+	if name == "" {
+		return nil
+	}
+	if i := strings.IndexAny(name, "'’"); i > 0 {
+		return []string{name[:i]}
+	}
+
+	return dropDative(name, turkishDatives)
+}
+
+// kazakhDatives — окончания дательного падежа в казахском:
+// Маратқа → Марат, Айгүлге → Айгүл, Сәулеге → Сәуле.
+var kazakhDatives = []string{"ға", "ге", "қа", "ке"}
+
+// kazakhNominative возвращает возможные начальные формы имени, произнесённого
+// в дательном падеже.
+func kazakhNominative(name string) []string {
+	if name == "" {
+		return nil
+	}
+
+	return dropDative(name, kazakhDatives)
+}
+
+// dropDative отрезает первое подходящее окончание из endings. Последним
+// кандидатом, как и в Nominative, идёт исходное слово.
+func dropDative(name string, endings []string) []string {
+	lower := strings.ToLower(name)
+
+	var candidates []string
+	for _, e := range endings {
+		if strings.HasSuffix(lower, e) && len([]rune(lower)) > len([]rune(e))+1 {
+			candidates = append(candidates, string([]rune(name)[:len([]rune(name))-len([]rune(e))]))
+			break
+		}
+	}
+
+	return appendUnique(candidates, name)
+}
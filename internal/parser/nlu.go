@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"math"
+	"strings"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/models"
+)
+
+// ParseSendNLU разбирает команду отправки по сущности YANDEX.FIO из NLU Алисы.
+// Алиса сама приводит имя к именительному падежу, поэтому такой разбор надёжнее
+// ParseSend. Если в запросе нет имени, возвращает false.
+func (l *Lexicon) ParseSendNLU(utterance string, nlu models.NLU) (SendCommand, bool) {
+	e, ok := nlu.Entity(models.EntityFIO)
+	if !ok {
+		return SendCommand{}, false
+	}
+
+	fio, ok := e.FIO()
+	if !ok || (fio.FirstName == "" && fio.LastName == "") {
+		return SendCommand{}, false
+	}
+
+	start, end := clampRange(e.Tokens, len(nlu.Tokens))
+
+	var cmd SendCommand
+	cmd.Recipient = strings.Join(nlu.Tokens[start:end], " ")
+
+	for _, name := range []string{
+		strings.TrimSpace(fio.FirstName + " " + fio.LastName),
+		fio.FirstName,
+		fio.LastName,
+	} {
+		if name != "" {
+			cmd.Candidates = appendUnique(cmd.Candidates, name)
+		}
+	}
+
+	// токены Алисы не сохраняют кавычки, поэтому текст в кавычках берём из исходной фразы
+	if _, quoted, ok := extractQuoted(utterance); ok {
+		cmd.Message = quoted
+		return cmd, true
+	}
+
+	// текст обычно идёт после имени, но его могут произнести и до: «отправь привет Маше».
+	// Глагол в начале фразы может и отсутствовать, если имя называют в ответ на «Кому?»
+	cmd.Message = l.joinMessage(nlu.Tokens[end:])
+	if cmd.Message == "" {
+		before, _ := l.cut(nlu.Tokens[:start], CommandSend)
+		cmd.Message = l.joinMessage(before)
+	}
+
+	return cmd, true
+}
+
+// ParseReadNLU находит номер сообщения по сущности YANDEX.NUMBER
+// («прочитай сообщение номер три») и возвращает индекс, начиная с нуля.
+func ParseReadNLU(nlu models.NLU) (int, bool) {
+	e, ok := nlu.Entity(models.EntityNumber)
+	if !ok {
+		return 0, false
+	}
+
+	n, ok := e.Number()
+	if !ok || n < 1 || n != math.Trunc(n) {
+		return 0, false
+	}
+
+	return int(n) - 1, true
+}
+
+func clampRange(r models.TokensRange, n int) (start, end int) {
+	start, end = r.Start, r.End
+	if start < 0 {
+		start = 0
+	}
+	if end > n {
+		end = n
+	}
+	if start > end {
+		start = end
+	}
+
+	return start, end
+}
@@ -0,0 +1,212 @@
+package parser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// LastIndex — индекс, означающий последнее сообщение («прочитай последнее»).
+const LastIndex = -1
+
+// SendCommand — разобранная команда отправки сообщения.
+type SendCommand struct {
+	// Recipient — имя получателя в той форме, в которой его произнесли («Маше»).
+	Recipient string
+	// Candidates — возможные начальные формы имени, начиная с наиболее вероятной.
+	Candidates []string
+	// Message — текст сообщения.
+	Message string
+}
+
+// ParseSend разбирает команду вида «Отправь Маше сообщение привет».
+// Имя получателя может стоять как до, так и после слова «сообщение»,
+// текст сообщения может быть заключён в кавычки или отделён двоеточием.
+func (l *Lexicon) ParseSend(command string) SendCommand {
+	rest, quoted, hasQuoted := extractQuoted(command)
+	words := strings.Fields(l.strip(rest, CommandSend))
+
+	var cmd SendCommand
+	for i, w := range words {
+		clean := trimPunct(w)
+		if clean == "" || l.messageWords[strings.ToLower(clean)] {
+			continue
+		}
+
+		cmd.Recipient = clean
+		if !hasQuoted {
+			cmd.Message = l.joinMessage(words[i+1:])
+		}
+		break
+	}
+
+	if hasQuoted {
+		cmd.Message = quoted
+	}
+	cmd.Candidates = l.names(cmd.Recipient)
+
+	return cmd
+}
+
+// ParseRead разбирает команду вида «Прочитай второе сообщение» и возвращает
+// индекс сообщения, начиная с нуля, или LastIndex для последнего сообщения.
+// Если номер не назван, возвращается первое сообщение.
+func (l *Lexicon) ParseRead(command string) int {
+	for _, w := range strings.Fields(l.strip(command, CommandRead)) {
+		w = strings.ToLower(trimPunct(w))
+
+		if l.lastWords[w] {
+			return LastIndex
+		}
+		if n, ok := l.number(w); ok {
+			return n - 1
+		}
+	}
+
+	return 0
+}
+
+// ParseRegister разбирает команду вида «Зарегистрируй меня под именем Маша»
+// и возвращает имя пользователя. Служебные слова отбрасываются и после имени:
+// «beni Maşa olarak kaydet».
+func (l *Lexicon) ParseRegister(command string) string {
+	rest, quoted, hasQuoted := extractQuoted(command)
+	if hasQuoted {
+		return quoted
+	}
+
+	var name []string
+	for _, w := range strings.Fields(l.strip(rest, CommandRegister)) {
+		clean := trimPunct(w)
+		if clean == "" || (len(name) == 0 && l.registerFillers[strings.ToLower(clean)]) {
+			continue
+		}
+		name = append(name, clean)
+	}
+	for len(name) > 1 && l.registerFillers[strings.ToLower(name[len(name)-1])] {
+		name = name[:len(name)-1]
+	}
+
+	return strings.Join(name, " ")
+}
+
+// ParseReply разбирает команду вида «Ответь: буду в семь» и возвращает текст ответа.
+// Если команда в кавычках, возвращается текст в кавычках.
+func (l *Lexicon) ParseReply(command string) string {
+	if _, quoted, ok := extractQuoted(command); ok {
+		return quoted
+	}
+
+	return l.joinMessage(strings.Fields(l.strip(command, CommandReply)))
+}
+
+var quotePairs = [][2]rune{
+	{'«', '»'},
+	{'“', '”'},
+	{'„', '“'},
+	{'"', '"'},
+	{'\'', '\''},
+}
+
+// extractQuoted находит первый фрагмент в кавычках и возвращает команду без него
+// и сам фрагмент.
+func extractQuoted(command string) (rest, quoted string, ok bool) {
+	for _, q := range quotePairs {
+		start := strings.IndexRune(command, q[0])
+		if start < 0 {
+			continue
+		}
+
+		openLen := len(string(q[0]))
+		end := strings.IndexRune(command[start+openLen:], q[1])
+		if end < 0 {
+			continue
+		}
+		end += start + openLen
+
+		quoted = strings.TrimSpace(command[start+openLen : end])
+		rest = command[:start] + command[end+len(string(q[1])):]
+		return rest, quoted, true
+	}
+
+	return command, "", false
+}
+
+// joinMessage собирает текст сообщения, пропуская служебное слово в начале.
+func (l *Lexicon) joinMessage(words []string) string {
+	for len(words) > 0 {
+		first := trimPunct(words[0])
+		if first != "" && !l.messageWords[strings.ToLower(first)] {
+			break
+		}
+		words = words[1:]
+	}
+
+	return strings.TrimSpace(strings.TrimLeft(strings.Join(words, " "), ":,— -"))
+}
+
+func trimPunct(s string) string {
+	return strings.TrimFunc(s, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSymbol(r)
+	})
+}
+
+// IsCancel сообщает, что реплика целиком — просьба прекратить («отмена», «не надо»).
+// В отличие от IsReject не срабатывает на фразы, которые лишь начинаются с «нет».
+func (l *Lexicon) IsCancel(command string) bool {
+	return l.cancelPhrases[strings.ToLower(strings.Join(strings.Fields(trimPunct(command)), " "))]
+}
+
+// IsConfirm сообщает, что реплика — согласие («да», «отправляй»).
+func (l *Lexicon) IsConfirm(command string) bool {
+	return firstWordIn(command, l.confirmWords)
+}
+
+// IsReject сообщает, что реплика — отказ («нет», «не надо», «отмена»).
+func (l *Lexicon) IsReject(command string) bool {
+	return firstWordIn(command, l.rejectWords)
+}
+
+func firstWordIn(command string, words map[string]bool) bool {
+	fields := strings.Fields(command)
+	return len(fields) > 0 && words[strings.ToLower(trimPunct(fields[0]))]
+}
+
+// DeleteScope — что удалить по команде «Удали ...».
+type DeleteScope int
+
+const (
+	// DeleteOne — одно сообщение по номеру.
+	DeleteOne DeleteScope = iota
+	// DeleteRead — все прочитанные сообщения.
+	DeleteRead
+	// DeleteAll — все сообщения, «Очисти входящие».
+	DeleteAll
+)
+
+// ParseDelete разбирает команды «Удали второе сообщение», «Удали все прочитанные»
+// и «Очисти входящие». Для DeleteOne возвращает индекс как ParseRead.
+func (l *Lexicon) ParseDelete(command string) (DeleteScope, int) {
+	if l.Is(command, CommandClear) {
+		return DeleteAll, 0
+	}
+
+	command = l.strip(command, CommandDelete)
+	all := false
+	for _, w := range strings.Fields(command) {
+		w = strings.ToLower(trimPunct(w))
+		for _, prefix := range l.readPrefixes {
+			if strings.HasPrefix(w, prefix) {
+				return DeleteRead, 0
+			}
+		}
+		if l.allWords[w] {
+			all = true
+		}
+	}
+
+	if all {
+		return DeleteAll, 0
+	}
+
+	return DeleteOne, l.ParseRead(command)
+}
@@ -0,0 +1,468 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSend(t *testing.T) {
+	testCases := []struct {
+		name      string
+		command   string
+		recipient string
+		candidate string
+		message   string
+	}{
+		{
+			name:      "name_then_message_word",
+			command:   "Отправь Маше сообщение привет",
+			recipient: "Маше",
+			candidate: "Маша",
+			message:   "привет",
+		},
+		{
+			name:      "message_word_then_name",
+			command:   "отправь сообщение ивану как дела",
+			recipient: "ивану",
+			candidate: "иван",
+			message:   "как дела",
+		},
+		{
+			name:      "colon",
+			command:   "Отправь Игорю: буду в семь",
+			recipient: "Игорю",
+			candidate: "Игорь",
+			message:   "буду в семь",
+		},
+		{
+			name:      "quoted",
+			command:   "Отправь Марии «привет, как дела?»",
+			recipient: "Марии",
+			candidate: "Мария",
+			message:   "привет, как дела?",
+		},
+		{
+			name:      "double_quoted_before_name",
+			command:   `Отправь сообщение "скоро буду" Илье`,
+			recipient: "Илье",
+			candidate: "Илья",
+			message:   "скоро буду",
+		},
+		{
+			name:      "without_message",
+			command:   "Отправь Маше",
+			recipient: "Маше",
+			candidate: "Маша",
+			message:   "",
+		},
+		{
+			name:    "empty",
+			command: "Отправь",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := Russian.ParseSend(tc.command)
+
+			assert.Equal(t, tc.recipient, cmd.Recipient)
+			assert.Equal(t, tc.message, cmd.Message)
+			if tc.candidate != "" {
+				assert.Equal(t, tc.candidate, cmd.Candidates[0])
+			} else {
+				assert.Empty(t, cmd.Candidates)
+			}
+		})
+	}
+}
+
+func TestParseRead(t *testing.T) {
+	testCases := []struct {
+		command  string
+		expected int
+	}{
+		{command: "Прочитай сообщение", expected: 0},
+		{command: "прочитай первое сообщение", expected: 0},
+		{command: "прочитай второе", expected: 1},
+		{command: "Прочитай четвёртое сообщение", expected: 3},
+		{command: "прочитай последнее сообщение", expected: LastIndex},
+		{command: "прочитай сообщение номер три", expected: 2},
+		{command: "прочитай 5 сообщение", expected: 4},
+		{command: "прочти 2-е", expected: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.command, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Russian.ParseRead(tc.command))
+		})
+	}
+}
+
+func TestParseRegister(t *testing.T) {
+	testCases := []struct {
+		command  string
+		expected string
+	}{
+		{command: "Зарегистрируй Маша", expected: "Маша"},
+		{command: "зарегистрируй меня под именем маша", expected: "маша"},
+		{command: "Зарегистрируй меня как «Иван Петров»", expected: "Иван Петров"},
+		{command: "зарегистрируй меня с ником как", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.command, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Russian.ParseRegister(tc.command))
+		})
+	}
+}
+
+func TestParseReply(t *testing.T) {
+	testCases := []struct {
+		command  string
+		expected string
+	}{
+		{command: "Ответь: буду в семь", expected: "буду в семь"},
+		{command: "ответь буду в семь", expected: "буду в семь"},
+		{command: "Ответь «Хорошо, жду!»", expected: "Хорошо, жду!"},
+		{command: "Ответь", expected: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.command, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Russian.ParseReply(tc.command))
+		})
+	}
+}
+
+func TestParseDelete(t *testing.T) {
+	testCases := []struct {
+		command string
+		scope   DeleteScope
+		index   int
+	}{
+		{command: "удали сообщение", scope: DeleteOne, index: 0},
+		{command: "Удали второе сообщение", scope: DeleteOne, index: 1},
+		{command: "удали последнее", scope: DeleteOne, index: LastIndex},
+		{command: "удали все прочитанные", scope: DeleteRead},
+		{command: "удали прочитанные сообщения", scope: DeleteRead},
+		{command: "удали все сообщения", scope: DeleteAll},
+		{command: "Очисти входящие", scope: DeleteAll},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.command, func(t *testing.T) {
+			scope, index := Russian.ParseDelete(tc.command)
+			assert.Equal(t, tc.scope, scope)
+			assert.Equal(t, tc.index, index)
+		})
+	}
+}
+
+func TestConfirmReject(t *testing.T) {
+	testCases := []struct {
+		command string
+		confirm bool
+		reject  bool
+		cancel  bool
+	}{
+		{command: "да", confirm: true},
+		{command: "Да, отправляй", confirm: true},
+		{command: "отправь Пете привет"},
+		{command: "нет", reject: true},
+		{command: "Не надо!", reject: true, cancel: true},
+		{command: "отмена", reject: true, cancel: true},
+		{command: "нет, я не приду", reject: true},
+		{command: "привет"},
+		{command: ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.command, func(t *testing.T) {
+			assert.Equal(t, tc.confirm, Russian.IsConfirm(tc.command))
+			assert.Equal(t, tc.reject, Russian.IsReject(tc.command))
+			assert.Equal(t, tc.cancel, Russian.IsCancel(tc.command))
+		})
+	}
+}
+
+func TestNominative(t *testing.T) {
+	testCases := []struct {
+		name     string
+		expected []string
+	}{
+		{name: "Маше", expected: []string{"Маша", "Маше"}},
+		{name: "Пете", expected: []string{"Петя", "Пета", "Пете"}},
+		{name: "Коле", expected: []string{"Коля", "Кола", "Коле"}},
+		{name: "Вере", expected: []string{"Вера", "Веря", "Вере"}},
+		{name: "Анне", expected: []string{"Анна", "Ання", "Анне"}},
+		{name: "Ивану", expected: []string{"Иван", "Ивану"}},
+		{name: "Андрею", expected: []string{"Андрей", "Андрею"}},
+		{name: "Зое", expected: []string{"Зоя", "Зое"}},
+		{name: "Марии", expected: []string{"Мария", "Марии"}},
+		{name: "Любови", expected: []string{"Любовь", "Любови"}},
+		{name: "ПЕТЕ", expected: []string{"ПЕТЯ", "ПЕТА", "ПЕТЕ"}},
+		{name: "Ким", expected: []string{"Ким"}},
+		{name: "", expected: nil},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Nominative(tc.name))
+		})
+	}
+}
+
+func TestFor(t *testing.T) {
+	testCases := []struct {
+		locale   string
+		expected *Lexicon
+	}{
+		{locale: "ru-RU", expected: Russian},
+		{locale: "en-US", expected: English},
+		{locale: "en-GB", expected: English},
+		{locale: "tr-TR", expected: Turkish},
+		{locale: "kk-KZ", expected: Kazakh},
+		{locale: "fr-FR", expected: Russian},
+		{locale: "", expected: Russian},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.locale, func(t *testing.T) {
+			assert.Same(t, tc.expected, For(tc.locale))
+		})
+	}
+}
+
+func TestIs(t *testing.T) {
+	testCases := []struct {
+		name     string
+		lexicon  *Lexicon
+		command  string
+		c        Command
+		expected bool
+	}{
+		{name: "whole_word", lexicon: Russian, command: "Пока, Алиса!", c: CommandExit, expected: true},
+		{name: "word_prefix", lexicon: Russian, command: "покажи сообщения", c: CommandExit},
+		{name: "word_not_first", lexicon: Russian, command: "ну пока", c: CommandExit},
+		{name: "phrase", lexicon: Russian, command: "покажи сообщения пожалуйста", c: CommandInbox, expected: true},
+		{name: "empty", lexicon: Russian, command: "", c: CommandExit},
+		{name: "english", lexicon: English, command: "show messages", c: CommandInbox, expected: true},
+		{name: "russian_in_english", lexicon: English, command: "покажи сообщения", c: CommandInbox},
+		{name: "verb_final", lexicon: Turkish, command: "ikinci mesajı oku", c: CommandRead, expected: true},
+		{name: "verb_first", lexicon: Turkish, command: "oku ikinci mesajı", c: CommandRead, expected: true},
+		{name: "exit_only_first", lexicon: Kazakh, command: "кешке келемін сау бол", c: CommandExit},
+		{name: "exit", lexicon: Kazakh, command: "сау бол", c: CommandExit, expected: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.lexicon.Is(tc.command, tc.c))
+		})
+	}
+}
+
+func TestLexicons(t *testing.T) {
+	t.Run("send", func(t *testing.T) {
+		testCases := []struct {
+			lexicon   *Lexicon
+			command   string
+			candidate string
+			message   string
+		}{
+			{lexicon: English, command: "send a message to masha saying see you at seven", candidate: "masha", message: "see you at seven"},
+			{lexicon: English, command: "tell petya that i am late", candidate: "petya", message: "i am late"},
+			{lexicon: Turkish, command: "ayşeye merhaba gönder", candidate: "ayşe", message: "merhaba"},
+			{lexicon: Turkish, command: "Ahmet'e «yedide geliyorum» gönder", candidate: "Ahmet", message: "yedide geliyorum"},
+			{lexicon: Kazakh, command: "маратқа сәлем жібер", candidate: "марат", message: "сәлем"},
+		}
+
+		for _, tc := range testCases {
+			cmd := tc.lexicon.ParseSend(tc.command)
+			require.NotEmpty(t, cmd.Candidates, tc.command)
+			assert.Equal(t, tc.candidate, cmd.Candidates[0], tc.command)
+			assert.Equal(t, tc.message, cmd.Message, tc.command)
+		}
+	})
+
+	t.Run("read", func(t *testing.T) {
+		assert.Equal(t, 1, English.ParseRead("read the second message"))
+		assert.Equal(t, 2, English.ParseRead("read message 3rd"))
+		assert.Equal(t, LastIndex, English.ParseRead("read the last message"))
+		assert.Equal(t, 2, Turkish.ParseRead("üçüncü mesajı oku"))
+		assert.Equal(t, 1, Kazakh.ParseRead("2-ші хабарламаны оқы"))
+	})
+
+	t.Run("register", func(t *testing.T) {
+		assert.Equal(t, "masha", English.ParseRegister("register me as masha"))
+		assert.Equal(t, "maşa", Turkish.ParseRegister("beni maşa olarak kaydet"))
+		assert.Equal(t, "марат", Kazakh.ParseRegister("мені марат деп тірке"))
+	})
+
+	t.Run("reply", func(t *testing.T) {
+		assert.Equal(t, "see you at seven", English.ParseReply("reply see you at seven"))
+		assert.Equal(t, "tamam", Turkish.ParseReply("tamam yanıtla"))
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		testCases := []struct {
+			lexicon *Lexicon
+			command string
+			scope   DeleteScope
+			index   int
+		}{
+			{lexicon: English, command: "delete message 7", scope: DeleteOne, index: 6},
+			{lexicon: English, command: "delete all read messages", scope: DeleteRead},
+			{lexicon: English, command: "clear inbox", scope: DeleteAll},
+			{lexicon: Turkish, command: "ikinci mesajı sil", scope: DeleteOne, index: 1},
+			{lexicon: Turkish, command: "okunan mesajları sil", scope: DeleteRead},
+			{lexicon: Kazakh, command: "барлық хабарламаларды жой", scope: DeleteAll},
+		}
+
+		for _, tc := range testCases {
+			scope, index := tc.lexicon.ParseDelete(tc.command)
+			assert.Equal(t, tc.scope, scope, tc.command)
+			assert.Equal(t, tc.index, index, tc.command)
+		}
+	})
+
+	t.Run("confirm", func(t *testing.T) {
+		assert.True(t, English.IsConfirm("yes"))
+		assert.False(t, English.IsConfirm("send it to petya"))
+		assert.True(t, English.IsReject("no"))
+		assert.True(t, English.IsCancel("never mind"))
+		assert.True(t, Turkish.IsConfirm("evet"))
+		assert.True(t, Turkish.IsReject("hayır"))
+		assert.True(t, Kazakh.IsConfirm("иә"))
+		assert.True(t, Kazakh.IsReject("жоқ"))
+		assert.False(t, English.IsConfirm("да"))
+	})
+}
+
+func TestDative(t *testing.T) {
+	assert.Equal(t, []string{"Ayşe", "Ayşeye"}, turkishNominative("Ayşeye"))
+	assert.Equal(t, []string{"ahmet", "ahmete"}, turkishNominative("ahmete"))
+	assert.Equal(t, []string{"Ahmet"}, turkishNominative("Ahmet'e"))
+	assert.Equal(t, []string{"Айгүл", "Айгүлге"}, kazakhNominative("Айгүлге"))
+	assert.Equal(t, []string{"Ким"}, kazakhNominative("Ким"))
+	assert.Equal(t, []string{"masha"}, asSpoken("masha"))
+	assert.Nil(t, turkishNominative(""))
+}
+
+func TestParseSendNLU(t *testing.T) {
+	testCases := []struct {
+		name       string
+		utterance  string
+		nlu        string
+		ok         bool
+		recipient  string
+		candidates []string
+		message    string
+	}{
+		{
+			name:      "full_name",
+			utterance: "Отправь Ивану Петрову буду в семь",
+			nlu: `{"tokens": ["отправь", "ивану", "петрову", "буду", "в", "7"],
+				"entities": [{"type": "YANDEX.FIO", "tokens": {"start": 1, "end": 3},
+					"value": {"first_name": "иван", "last_name": "петров"}},
+					{"type": "YANDEX.NUMBER", "tokens": {"start": 5, "end": 6}, "value": 7}]}`,
+			ok:         true,
+			recipient:  "ивану петрову",
+			candidates: []string{"иван петров", "иван", "петров"},
+			message:    "буду в 7",
+		},
+		{
+			name:      "message_before_name",
+			utterance: "Отправь сообщение привет Маше",
+			nlu: `{"tokens": ["отправь", "сообщение", "привет", "маше"],
+				"entities": [{"type": "YANDEX.FIO", "tokens": {"start": 3, "end": 4},
+					"value": {"first_name": "маша"}}]}`,
+			ok:         true,
+			recipient:  "маше",
+			candidates: []string{"маша"},
+			message:    "привет",
+		},
+		{
+			name:      "quoted",
+			utterance: "Отправь Маше «Привет, как дела?»",
+			nlu: `{"tokens": ["отправь", "маше", "привет", "как", "дела"],
+				"entities": [{"type": "YANDEX.FIO", "tokens": {"start": 1, "end": 2},
+					"value": {"first_name": "маша"}}]}`,
+			ok:         true,
+			recipient:  "маше",
+			candidates: []string{"маша"},
+			message:    "Привет, как дела?",
+		},
+		{
+			name:      "message_before_name_without_verb",
+			utterance: "Привет Маше",
+			nlu: `{"tokens": ["привет", "маше"],
+				"entities": [{"type": "YANDEX.FIO", "tokens": {"start": 1, "end": 2},
+					"value": {"first_name": "маша"}}]}`,
+			ok:         true,
+			recipient:  "маше",
+			candidates: []string{"маша"},
+			message:    "привет",
+		},
+		{
+			name:      "without_fio",
+			utterance: "Отправь Маше привет",
+			nlu:       `{"tokens": ["отправь", "маше", "привет"], "entities": []}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var nlu models.NLU
+			require.NoError(t, json.Unmarshal([]byte(tc.nlu), &nlu))
+
+			cmd, ok := Russian.ParseSendNLU(tc.utterance, nlu)
+			require.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.recipient, cmd.Recipient)
+			assert.Equal(t, tc.candidates, cmd.Candidates)
+			assert.Equal(t, tc.message, cmd.Message)
+		})
+	}
+}
+
+func TestParseReadNLU(t *testing.T) {
+	testCases := []struct {
+		name     string
+		nlu      string
+		ok       bool
+		expected int
+	}{
+		{
+			name:     "number",
+			nlu:      `{"entities": [{"type": "YANDEX.NUMBER", "tokens": {"start": 3, "end": 4}, "value": 3}]}`,
+			ok:       true,
+			expected: 2,
+		},
+		{
+			name: "fraction",
+			nlu:  `{"entities": [{"type": "YANDEX.NUMBER", "tokens": {"start": 3, "end": 4}, "value": 2.5}]}`,
+		},
+		{
+			name: "zero",
+			nlu:  `{"entities": [{"type": "YANDEX.NUMBER", "tokens": {"start": 3, "end": 4}, "value": 0}]}`,
+		},
+		{
+			name: "no_number",
+			nlu:  `{"entities": []}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var nlu models.NLU
+			require.NoError(t, json.Unmarshal([]byte(tc.nlu), &nlu))
+
+			idx, ok := ParseReadNLU(nlu)
+			require.Equal(t, tc.ok, ok)
+			assert.Equal(t, tc.expected, idx)
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/models"
+)
+
+// ErrNoHandler возвращается, если запросу не подошёл ни один обработчик.
+var ErrNoHandler = errors.New("no handler for request")
+
+// Handler — обработчик одной команды (интента) навыка.
+type Handler interface {
+	// Match сообщает, должен ли обработчик обслужить запрос.
+	Match(req *models.Request) bool
+	// Handle обрабатывает запрос и заполняет ответ навыка.
+	Handle(ctx context.Context, req *models.Request, resp *models.Response) error
+}
+
+// Router выбирает обработчик для входящего запроса.
+// Обработчики проверяются в порядке регистрации.
+type Router struct {
+	handlers []Handler
+	fallback Handler
+}
+
+func New() *Router {
+	return &Router{}
+}
+
+// Register добавляет обработчик в конец списка.
+func (r *Router) Register(h Handler) {
+	r.handlers = append(r.handlers, h)
+}
+
+// Fallback задаёт обработчик для запросов, которые не подошли ни одному зарегистрированному.
+func (r *Router) Fallback(h Handler) {
+	r.fallback = h
+}
+
+// Route возвращает первый подходящий обработчик.
+func (r *Router) Route(req *models.Request) (Handler, error) {
+	for _, h := range r.handlers {
+		if h.Match(req) {
+			return h, nil
+		}
+	}
+
+	if r.fallback != nil {
+		return r.fallback, nil
+	}
+
+	return nil, ErrNoHandler
+}
+
+// HasCommandPrefix проверяет, начинается ли команда запроса с префикса.
+// Алиса присылает команду в нижнем регистре, поэтому сравнение регистронезависимое.
+func HasCommandPrefix(req *models.Request, prefix string) bool {
+	return strings.HasPrefix(strings.ToLower(req.Request.Command), strings.ToLower(prefix))
+}
+
+// HasAction проверяет, что запрос — нажатие кнопки с заданным действием.
+func HasAction(req *models.Request, action string) bool {
+	p, ok := req.Request.ButtonPayload()
+	return ok && p.Action == action
+}
@@ -0,0 +1,75 @@
+package router
+
+import (
+	"context"
+	"testing"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type prefixHandler struct {
+	prefix string
+}
+
+func (h prefixHandler) Match(req *models.Request) bool {
+	return HasCommandPrefix(req, h.prefix)
+}
+
+func (h prefixHandler) Handle(_ context.Context, _ *models.Request, resp *models.Response) error {
+	resp.Response.Text = h.prefix
+	return nil
+}
+
+func TestRoute(t *testing.T) {
+	r := New()
+	r.Register(prefixHandler{prefix: "Отправь"})
+	r.Register(prefixHandler{prefix: "Прочитай"})
+
+	testCases := []struct {
+		name     string
+		command  string
+		fallback Handler
+		expected Handler
+		err      error
+	}{
+		{
+			name:     "first_handler",
+			command:  "отправь маше привет",
+			expected: prefixHandler{prefix: "Отправь"},
+		},
+		{
+			name:     "second_handler",
+			command:  "Прочитай первое сообщение",
+			expected: prefixHandler{prefix: "Прочитай"},
+		},
+		{
+			name:    "no_handler",
+			command: "сколько времени",
+			err:     ErrNoHandler,
+		},
+		{
+			name:     "fallback",
+			command:  "сколько времени",
+			fallback: prefixHandler{prefix: ""},
+			expected: prefixHandler{prefix: ""},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r.Fallback(tc.fallback)
+
+			req := &models.Request{Request: models.SimpleUtterance{Command: tc.command}}
+			h, err := r.Route(req)
+			if tc.err != nil {
+				require.ErrorIs(t, err, tc.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, h)
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Package cached кэширует списки сообщений поверх любого store.Store.
+package cached
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/cache"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+)
+
+// Store запоминает ListMessages, включая постраничные выборки, и ListUnread
+// для каждого пользователя и сбрасывает их, когда сообщения пользователя меняются.
+//
+// Ключи списков включают поколение: своё у каждого пользователя и общее для всех.
+// Сброс удаляет ключ поколения, и следующий запрос начинает новое, поэтому
+// не нужно перечислять все закэшированные страницы. Страницы старого поколения
+// больше не читаются и удаляются по истечении ttl. Общее поколение сбрасывает
+// регистрация: от её имени зависит, какие сообщения видны получателям.
+//
+// Ошибки кэша не мешают работе навыка: при недоступном кэше запросы идут
+// в хранилище, а записи, которые не удалось сбросить, живут не дольше ttl.
+type Store struct {
+	store.Store
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+func NewStore(s store.Store, c cache.Cache, ttl time.Duration) *Store {
+	return &Store{
+		Store: s,
+		cache: c,
+		ttl:   ttl,
+	}
+}
+
+// usersGenerationKey — ключ общего поколения списков всех пользователей.
+const usersGenerationKey = "generation"
+
+func generationKey(userID string) string {
+	return "generation:" + userID
+}
+
+func (s *Store) ListMessages(ctx context.Context, userID string, opts store.ListOptions) ([]store.Message, error) {
+	key := fmt.Sprintf("messages:%d:%d:%d:%d:%t:%s", opts.Limit, opts.Offset, opts.After, opts.Order, opts.UnreadOnly, opts.Sender)
+	return s.list(ctx, userID, key, func() ([]store.Message, error) {
+		return s.Store.ListMessages(ctx, userID, opts)
+	})
+}
+
+func (s *Store) ListUnread(ctx context.Context, userID string) ([]store.Message, error) {
+	return s.list(ctx, userID, "unread", func() ([]store.Message, error) {
+		return s.Store.ListUnread(ctx, userID)
+	})
+}
+
+// generation возвращает текущее поколение по ключу key, начиная новое, если его нет.
+func (s *Store) generation(ctx context.Context, key string) (string, error) {
+	data, ok, err := s.cache.Get(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if ok {
+		return string(data), nil
+	}
+
+	// поколение не должно совпасть ни с одним из тех, чьи записи ещё живы
+	generation := strconv.FormatInt(time.Now().UnixNano(), 36)
+	if err := s.cache.Set(ctx, key, []byte(generation), s.ttl); err != nil {
+		return "", err
+	}
+
+	return generation, nil
+}
+
+// list возвращает список пользователя из кэша, а если его там нет — загружает и запоминает.
+func (s *Store) list(ctx context.Context, userID, key string, load func() ([]store.Message, error)) ([]store.Message, error) {
+	users, err := s.generation(ctx, usersGenerationKey)
+	if err != nil {
+		return load()
+	}
+	user, err := s.generation(ctx, generationKey(userID))
+	if err != nil {
+		return load()
+	}
+	key = strings.Join([]string{users, user, userID, key}, ":")
+
+	if data, ok, err := s.cache.Get(ctx, key); err == nil && ok {
+		var messages []store.Message
+		if json.Unmarshal(data, &messages) == nil {
+			return messages, nil
+		}
+	}
+
+	messages, err := load()
+	if err != nil {
+		return nil, err
+	}
+
+	if data, err := json.Marshal(messages); err == nil {
+		s.cache.Set(ctx, key, data, s.ttl)
+	}
+
+	return messages, nil
+}
+
+// invalidate сбрасывает списки сообщений пользователя.
+func (s *Store) invalidate(ctx context.Context, userID string) {
+	s.cache.Delete(ctx, generationKey(userID))
+}
+
+// RegisterUser сбрасывает списки всех пользователей: в них появятся сообщения,
+// которые пользователь отправил до регистрации.
+func (s *Store) RegisterUser(ctx context.Context, userID, username string) error {
+	defer s.cache.Delete(ctx, usersGenerationKey)
+	return s.Store.RegisterUser(ctx, userID, username)
+}
+
+func (s *Store) SaveMessage(ctx context.Context, userID string, msg store.Message) error {
+	defer s.invalidate(ctx, userID)
+	return s.Store.SaveMessage(ctx, userID, msg)
+}
+
+func (s *Store) MarkRead(ctx context.Context, userID string, id int64) error {
+	defer s.invalidate(ctx, userID)
+	return s.Store.MarkRead(ctx, userID, id)
+}
+
+func (s *Store) DeleteMessage(ctx context.Context, userID string, id int64) error {
+	defer s.invalidate(ctx, userID)
+	return s.Store.DeleteMessage(ctx, userID, id)
+}
+
+func (s *Store) DeleteRead(ctx context.Context, userID string) (int64, error) {
+	defer s.invalidate(ctx, userID)
+	return s.Store.DeleteRead(ctx, userID)
+}
+
+func (s *Store) DeleteAll(ctx context.Context, userID string) (int64, error) {
+	defer s.invalidate(ctx, userID)
+	return s.Store.DeleteAll(ctx, userID)
+}
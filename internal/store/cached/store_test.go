@@ -0,0 +1,101 @@
+package cached
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/cache"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/cache/redistest"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store/memory"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store/mock"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store/storetest"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	t.Run("lru", func(t *testing.T) {
+		storetest.Run(t, func(t *testing.T) store.Store {
+			return NewStore(memory.NewStore(), cache.NewLRU(100), time.Minute)
+		})
+	})
+
+	t.Run("redis", func(t *testing.T) {
+		storetest.Run(t, func(t *testing.T) store.Store {
+			c := cache.NewRedis(redistest.NewServer(t).Addr, time.Second)
+			t.Cleanup(func() { c.Close() })
+
+			return NewStore(memory.NewStore(), c, time.Minute)
+		})
+	})
+}
+
+func TestInvalidation(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	m := mock.NewMockStore(ctrl)
+
+	messages := []store.Message{{ID: 1, Sender: "петя", Time: time.Now()}}
+
+	m.EXPECT().ListUnread(gomock.Any(), "masha-id").Return(messages, nil).Times(2)
+	m.EXPECT().ListMessages(gomock.Any(), "masha-id", store.ListOptions{}).Return(messages, nil).Times(1)
+	m.EXPECT().MarkRead(gomock.Any(), "masha-id", int64(1)).Return(nil)
+
+	s := NewStore(m, cache.NewLRU(100), time.Minute)
+
+	for i := 0; i < 3; i++ {
+		unread, err := s.ListUnread(ctx, "masha-id")
+		require.NoError(t, err)
+		assert.Len(t, unread, 1)
+
+		all, err := s.ListMessages(ctx, "masha-id", store.ListOptions{})
+		require.NoError(t, err)
+		assert.Len(t, all, 1)
+	}
+
+	require.NoError(t, s.MarkRead(ctx, "masha-id", 1))
+	_, err := s.ListUnread(ctx, "masha-id")
+	require.NoError(t, err)
+}
+
+func TestPages(t *testing.T) {
+	ctx := context.Background()
+	ctrl := gomock.NewController(t)
+	m := mock.NewMockStore(ctrl)
+
+	first := store.ListOptions{Limit: 1, Order: store.OrderDesc}
+	second := store.ListOptions{Limit: 1, Order: store.OrderDesc, After: 2}
+
+	// каждая страница загружается один раз до сброса и один раз после
+	m.EXPECT().ListMessages(gomock.Any(), "masha-id", first).Return([]store.Message{{ID: 2}}, nil).Times(2)
+	m.EXPECT().ListMessages(gomock.Any(), "masha-id", second).Return([]store.Message{{ID: 1}}, nil).Times(2)
+	m.EXPECT().DeleteMessage(gomock.Any(), "masha-id", int64(2)).Return(nil)
+	m.EXPECT().RegisterUser(gomock.Any(), "petya-id", "петя").Return(nil)
+
+	s := NewStore(m, cache.NewLRU(100), time.Minute)
+
+	list := func() {
+		for i := 0; i < 2; i++ {
+			page, err := s.ListMessages(ctx, "masha-id", first)
+			require.NoError(t, err)
+			assert.Equal(t, int64(2), page[0].ID)
+
+			page, err = s.ListMessages(ctx, "masha-id", second)
+			require.NoError(t, err)
+			assert.Equal(t, int64(1), page[0].ID)
+		}
+	}
+
+	list()
+	require.NoError(t, s.DeleteMessage(ctx, "masha-id", 2))
+	list()
+
+	// регистрация сбрасывает списки всех пользователей
+	m.EXPECT().ListMessages(gomock.Any(), "masha-id", first).Return([]store.Message{{ID: 2}}, nil)
+	require.NoError(t, s.RegisterUser(ctx, "petya-id", "петя"))
+	_, err := s.ListMessages(ctx, "masha-id", first)
+	require.NoError(t, err)
+}
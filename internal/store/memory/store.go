@@ -0,0 +1,415 @@
+package memory
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+)
+
+// Store хранит пользователей и сообщения в памяти процесса.
+// Подходит для локального запуска без базы и для тестов: семантика та же, что у sqlstore.Store.
+type Store struct {
+	mu sync.RWMutex
+
+	// usernames — имена пользователей по идентификатору
+	usernames map[string]string
+	// users — идентификаторы пользователей по имени
+	users    map[string]string
+	messages map[int64]*message
+	lastID   int64
+
+	// events — очередь outbox в порядке создания
+	events      []*event
+	lastEventID int64
+}
+
+type event struct {
+	store.Event
+	// availableAt — когда событие можно забрать снова
+	availableAt time.Time
+	failed      bool
+}
+
+type message struct {
+	store.Message
+	sender    string
+	recipient string
+	readAt    time.Time
+	deleted   bool
+}
+
+func NewStore() *Store {
+	return &Store{
+		usernames: make(map[string]string),
+		users:     make(map[string]string),
+		messages:  make(map[int64]*message),
+	}
+}
+
+// PingContext всегда успешен: хранилище в памяти доступно, пока жив процесс.
+func (s *Store) PingContext(ctx context.Context) error {
+	return nil
+}
+
+func (s *Store) FindRecipient(ctx context.Context, username string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	userID, ok := s.users[username]
+	if !ok {
+		return "", store.ErrNotFound
+	}
+
+	return userID, nil
+}
+
+func (s *Store) ListMessages(ctx context.Context, userID string, opts store.ListOptions) ([]store.Message, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	messages := s.list(func(m *message) bool {
+		return m.recipient == userID && (!opts.UnreadOnly || m.readAt.IsZero())
+	}, false)
+
+	if opts.Sender != "" {
+		filtered := messages[:0]
+		for _, m := range messages {
+			if m.Sender == opts.Sender {
+				filtered = append(filtered, m)
+			}
+		}
+		messages = filtered
+	}
+
+	if opts.Order == store.OrderDesc {
+		for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+			messages[i], messages[j] = messages[j], messages[i]
+		}
+	}
+
+	// курсор — позиция сообщения After в выборке без фильтров,
+	// поэтому сравниваем по времени и идентификатору, как sqlstore.Store
+	if opts.After != 0 {
+		cursor, ok := s.messages[opts.After]
+		if !ok {
+			return nil, nil
+		}
+
+		rest := messages[:0]
+		for _, m := range messages {
+			after := m.Time.After(cursor.Time) || (m.Time.Equal(cursor.Time) && m.ID > cursor.ID)
+			if opts.Order == store.OrderDesc {
+				after = m.Time.Before(cursor.Time) || (m.Time.Equal(cursor.Time) && m.ID < cursor.ID)
+			}
+			if after {
+				rest = append(rest, m)
+			}
+		}
+		messages = rest
+	}
+
+	if opts.Offset >= len(messages) {
+		return nil, nil
+	}
+	messages = messages[opts.Offset:]
+
+	if opts.Limit > 0 && opts.Limit < len(messages) {
+		messages = messages[:opts.Limit]
+	}
+
+	return messages, nil
+}
+
+func (s *Store) ListUnread(ctx context.Context, userID string) ([]store.Message, error) {
+	return s.ListMessages(ctx, userID, store.ListOptions{UnreadOnly: true})
+}
+
+// list возвращает неудалённые сообщения, подходящие под условие, в порядке отправки.
+// Как и в sqlstore.Store, сообщения от незарегистрированных отправителей не попадают в выборку.
+// Если full равен false, текст сообщения не заполняется.
+func (s *Store) list(match func(m *message) bool, full bool) []store.Message {
+	var messages []store.Message
+	for _, m := range s.messages {
+		username, ok := s.usernames[m.sender]
+		if m.deleted || !ok || !match(m) {
+			continue
+		}
+
+		msg := store.Message{ID: m.ID, Sender: username, Time: m.Time}
+		if full {
+			msg.Payload = m.Payload
+			msg.ReplyTo = m.ReplyTo
+		}
+		messages = append(messages, msg)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		if messages[i].Time.Equal(messages[j].Time) {
+			return messages[i].ID < messages[j].ID
+		}
+		return messages[i].Time.Before(messages[j].Time)
+	})
+
+	return messages
+}
+
+func (s *Store) GetMessage(ctx context.Context, userID string, id int64) (*store.Message, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m, err := s.checkAccess(userID, id, false)
+	if err != nil {
+		return nil, err
+	}
+
+	username, ok := s.usernames[m.sender]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+
+	msg := m.Message
+	msg.Sender = username
+	return &msg, nil
+}
+
+// checkAccess проверяет, что сообщение существует и адресовано пользователю.
+// Если allowSender равен true, доступ есть и у отправителя.
+func (s *Store) checkAccess(userID string, id int64, allowSender bool) (*message, error) {
+	m, ok := s.messages[id]
+	if !ok || m.deleted {
+		return nil, store.ErrNotFound
+	}
+
+	if m.recipient != userID && !(allowSender && m.sender == userID) {
+		return nil, store.ErrForbidden
+	}
+
+	return m, nil
+}
+
+// SaveMessage сохраняет сообщение и записывает событие EventMessageSaved в outbox.
+func (s *Store) SaveMessage(ctx context.Context, userID string, msg store.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sentAt := time.Now()
+	payload, err := json.Marshal(store.MessageSaved{
+		MessageID: s.lastID + 1,
+		Sender:    msg.Sender,
+		Recipient: userID,
+		Text:      msg.Payload,
+		ReplyTo:   msg.ReplyTo,
+		SentAt:    sentAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.lastID++
+	s.messages[s.lastID] = &message{
+		Message: store.Message{
+			ID:      s.lastID,
+			Time:    sentAt,
+			Payload: msg.Payload,
+			ReplyTo: msg.ReplyTo,
+		},
+		sender:    msg.Sender,
+		recipient: userID,
+	}
+
+	s.lastEventID++
+	s.events = append(s.events, &event{Event: store.Event{
+		ID:        s.lastEventID,
+		Type:      store.EventMessageSaved,
+		Payload:   payload,
+		CreatedAt: sentAt,
+	}})
+
+	return nil
+}
+
+func (s *Store) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]store.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var events []store.Event
+	for _, e := range s.events {
+		if len(events) == limit {
+			break
+		}
+		if e.failed || now.Before(e.availableAt) {
+			continue
+		}
+
+		e.Attempts++
+		e.availableAt = now.Add(lease)
+		events = append(events, e.Event)
+	}
+
+	return events, nil
+}
+
+func (s *Store) AckEvent(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, e := range s.events {
+		if e.ID == id {
+			s.events = append(s.events[:i], s.events[i+1:]...)
+			break
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) RetryEvent(ctx context.Context, id int64, retryAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e := s.event(id); e != nil {
+		e.availableAt = retryAt
+	}
+
+	return nil
+}
+
+func (s *Store) FailEvent(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e := s.event(id); e != nil {
+		e.failed = true
+	}
+
+	return nil
+}
+
+// event возвращает событие из очереди или nil, если его уже подтвердили.
+func (s *Store) event(id int64) *event {
+	for _, e := range s.events {
+		if e.ID == id {
+			return e
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) MarkRead(ctx context.Context, userID string, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.checkAccess(userID, id, false)
+	if err != nil {
+		return err
+	}
+
+	if m.readAt.IsZero() {
+		m.readAt = time.Now()
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteMessage(ctx context.Context, userID string, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	m, err := s.checkAccess(userID, id, false)
+	if err != nil {
+		return err
+	}
+
+	m.deleted = true
+	return nil
+}
+
+func (s *Store) DeleteRead(ctx context.Context, userID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.delete(func(m *message) bool {
+		return m.recipient == userID && !m.readAt.IsZero()
+	}), nil
+}
+
+func (s *Store) DeleteAll(ctx context.Context, userID string) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.delete(func(m *message) bool {
+		return m.recipient == userID
+	}), nil
+}
+
+// delete помечает удалёнными подходящие сообщения и возвращает их количество.
+func (s *Store) delete(match func(m *message) bool) int64 {
+	var n int64
+	for _, m := range s.messages {
+		if m.deleted || !match(m) {
+			continue
+		}
+
+		m.deleted = true
+		n++
+	}
+
+	return n
+}
+
+func (s *Store) ListThread(ctx context.Context, userID string, id int64) ([]store.Message, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	m, err := s.checkAccess(userID, id, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// поднимаемся к началу переписки, включая удалённые сообщения, как и sqlstore.Store
+	root := m
+	for root.ReplyTo != 0 {
+		parent, ok := s.messages[root.ReplyTo]
+		if !ok {
+			break
+		}
+		root = parent
+	}
+
+	thread := map[int64]bool{root.ID: true}
+	for added := true; added; {
+		added = false
+		for _, m := range s.messages {
+			if !thread[m.ID] && thread[m.ReplyTo] {
+				thread[m.ID] = true
+				added = true
+			}
+		}
+	}
+
+	return s.list(func(m *message) bool {
+		return thread[m.ID]
+	}, true), nil
+}
+
+func (s *Store) RegisterUser(ctx context.Context, userID, username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.usernames[userID]; ok {
+		return store.ErrConflict
+	}
+	if _, ok := s.users[username]; ok {
+		return store.ErrConflict
+	}
+
+	s.usernames[userID] = username
+	s.users[username] = userID
+	return nil
+}
@@ -7,6 +7,7 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	store "bitbucket.org/sotavant/yandex-alice-skill/internal/store"
 	gomock "github.com/golang/mock/gomock"
@@ -35,6 +36,50 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// DeleteAll mocks base method.
+func (m *MockStore) DeleteAll(ctx context.Context, userID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteAll", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteAll indicates an expected call of DeleteAll.
+func (mr *MockStoreMockRecorder) DeleteAll(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteAll", reflect.TypeOf((*MockStore)(nil).DeleteAll), ctx, userID)
+}
+
+// DeleteMessage mocks base method.
+func (m *MockStore) DeleteMessage(ctx context.Context, userID string, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMessage", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMessage indicates an expected call of DeleteMessage.
+func (mr *MockStoreMockRecorder) DeleteMessage(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMessage", reflect.TypeOf((*MockStore)(nil).DeleteMessage), ctx, userID, id)
+}
+
+// DeleteRead mocks base method.
+func (m *MockStore) DeleteRead(ctx context.Context, userID string) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteRead", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteRead indicates an expected call of DeleteRead.
+func (mr *MockStoreMockRecorder) DeleteRead(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteRead", reflect.TypeOf((*MockStore)(nil).DeleteRead), ctx, userID)
+}
+
 // FindRecipient mocks base method.
 func (m *MockStore) FindRecipient(ctx context.Context, username string) (string, error) {
 	m.ctrl.T.Helper()
@@ -51,33 +96,91 @@ func (mr *MockStoreMockRecorder) FindRecipient(ctx, username interface{}) *gomoc
 }
 
 // GetMessage mocks base method.
-func (m *MockStore) GetMessage(ctx context.Context, id int64) (*store.Message, error) {
+func (m *MockStore) GetMessage(ctx context.Context, userID string, id int64) (*store.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMessage", ctx, id)
+	ret := m.ctrl.Call(m, "GetMessage", ctx, userID, id)
 	ret0, _ := ret[0].(*store.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetMessage indicates an expected call of GetMessage.
-func (mr *MockStoreMockRecorder) GetMessage(ctx, id interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) GetMessage(ctx, userID, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessage", reflect.TypeOf((*MockStore)(nil).GetMessage), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMessage", reflect.TypeOf((*MockStore)(nil).GetMessage), ctx, userID, id)
 }
 
 // ListMessages mocks base method.
-func (m *MockStore) ListMessages(ctx context.Context, userID string) ([]store.Message, error) {
+func (m *MockStore) ListMessages(ctx context.Context, userID string, opts store.ListOptions) ([]store.Message, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ListMessages", ctx, userID)
+	ret := m.ctrl.Call(m, "ListMessages", ctx, userID, opts)
 	ret0, _ := ret[0].([]store.Message)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // ListMessages indicates an expected call of ListMessages.
-func (mr *MockStoreMockRecorder) ListMessages(ctx, userID interface{}) *gomock.Call {
+func (mr *MockStoreMockRecorder) ListMessages(ctx, userID, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMessages", reflect.TypeOf((*MockStore)(nil).ListMessages), ctx, userID, opts)
+}
+
+// ListThread mocks base method.
+func (m *MockStore) ListThread(ctx context.Context, userID string, id int64) ([]store.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListThread", ctx, userID, id)
+	ret0, _ := ret[0].([]store.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListThread indicates an expected call of ListThread.
+func (mr *MockStoreMockRecorder) ListThread(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListThread", reflect.TypeOf((*MockStore)(nil).ListThread), ctx, userID, id)
+}
+
+// ListUnread mocks base method.
+func (m *MockStore) ListUnread(ctx context.Context, userID string) ([]store.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUnread", ctx, userID)
+	ret0, _ := ret[0].([]store.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUnread indicates an expected call of ListUnread.
+func (mr *MockStoreMockRecorder) ListUnread(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUnread", reflect.TypeOf((*MockStore)(nil).ListUnread), ctx, userID)
+}
+
+// MarkRead mocks base method.
+func (m *MockStore) MarkRead(ctx context.Context, userID string, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkRead", ctx, userID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkRead indicates an expected call of MarkRead.
+func (mr *MockStoreMockRecorder) MarkRead(ctx, userID, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkRead", reflect.TypeOf((*MockStore)(nil).MarkRead), ctx, userID, id)
+}
+
+// RegisterUser mocks base method.
+func (m *MockStore) RegisterUser(ctx context.Context, userID, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterUser", ctx, userID, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterUser indicates an expected call of RegisterUser.
+func (mr *MockStoreMockRecorder) RegisterUser(ctx, userID, username interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMessages", reflect.TypeOf((*MockStore)(nil).ListMessages), ctx, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterUser", reflect.TypeOf((*MockStore)(nil).RegisterUser), ctx, userID, username)
 }
 
 // SaveMessage mocks base method.
@@ -93,3 +196,83 @@ func (mr *MockStoreMockRecorder) SaveMessage(ctx, userID, msg interface{}) *gomo
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveMessage", reflect.TypeOf((*MockStore)(nil).SaveMessage), ctx, userID, msg)
 }
+
+// MockOutbox is a mock of Outbox interface.
+type MockOutbox struct {
+	ctrl     *gomock.Controller
+	recorder *MockOutboxMockRecorder
+}
+
+// MockOutboxMockRecorder is the mock recorder for MockOutbox.
+type MockOutboxMockRecorder struct {
+	mock *MockOutbox
+}
+
+// NewMockOutbox creates a new mock instance.
+func NewMockOutbox(ctrl *gomock.Controller) *MockOutbox {
+	mock := &MockOutbox{ctrl: ctrl}
+	mock.recorder = &MockOutboxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOutbox) EXPECT() *MockOutboxMockRecorder {
+	return m.recorder
+}
+
+// AckEvent mocks base method.
+func (m *MockOutbox) AckEvent(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AckEvent", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AckEvent indicates an expected call of AckEvent.
+func (mr *MockOutboxMockRecorder) AckEvent(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AckEvent", reflect.TypeOf((*MockOutbox)(nil).AckEvent), ctx, id)
+}
+
+// ClaimEvents mocks base method.
+func (m *MockOutbox) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]store.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimEvents", ctx, limit, lease)
+	ret0, _ := ret[0].([]store.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimEvents indicates an expected call of ClaimEvents.
+func (mr *MockOutboxMockRecorder) ClaimEvents(ctx, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimEvents", reflect.TypeOf((*MockOutbox)(nil).ClaimEvents), ctx, limit, lease)
+}
+
+// FailEvent mocks base method.
+func (m *MockOutbox) FailEvent(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FailEvent", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// FailEvent indicates an expected call of FailEvent.
+func (mr *MockOutboxMockRecorder) FailEvent(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FailEvent", reflect.TypeOf((*MockOutbox)(nil).FailEvent), ctx, id)
+}
+
+// RetryEvent mocks base method.
+func (m *MockOutbox) RetryEvent(ctx context.Context, id int64, retryAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetryEvent", ctx, id, retryAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RetryEvent indicates an expected call of RetryEvent.
+func (mr *MockOutboxMockRecorder) RetryEvent(ctx, id, retryAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetryEvent", reflect.TypeOf((*MockOutbox)(nil).RetryEvent), ctx, id, retryAt)
+}
@@ -0,0 +1,40 @@
+package pg
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"io/fs"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// lockID — ключ advisory-блокировки, общий для всех экземпляров навыка.
+const lockID = 7_451_230_918
+
+// NewMigrator возвращает Migrator со схемой хранилища.
+// Несколько экземпляров навыка, запущенных одновременно, применяют миграции по очереди.
+func NewMigrator(conn *sql.DB) (*migrate.Migrator, error) {
+	fsys, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.New(conn, fsys, advisoryLocker{})
+}
+
+// advisoryLocker держит сессионную блокировку pg_advisory_lock на время миграций.
+type advisoryLocker struct{}
+
+func (advisoryLocker) Lock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `select pg_advisory_lock($1)`, lockID)
+	return err
+}
+
+func (advisoryLocker) Unlock(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `select pg_advisory_unlock($1)`, lockID)
+	return err
+}
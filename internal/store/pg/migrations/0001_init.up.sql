@@ -0,0 +1,17 @@
+create table if not exists users (
+    id varchar(128) primary key,
+    username varchar(128)
+);
+
+create unique index if not exists sender_idx on users (username);
+
+create table if not exists messages (
+    id serial primary key,
+    sender varchar(128),
+    recipient varchar(128),
+    payload text,
+    sent_at timestamp with time zone,
+    read_at timestamp with time zone default null
+);
+
+create index if not exists recipient_idx on messages (recipient);
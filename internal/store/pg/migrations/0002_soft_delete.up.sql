@@ -0,0 +1 @@
+alter table messages add column if not exists deleted_at timestamp with time zone default null;
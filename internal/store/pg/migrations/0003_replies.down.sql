@@ -0,0 +1,3 @@
+drop index if exists reply_to_idx;
+
+alter table messages drop column if exists reply_to;
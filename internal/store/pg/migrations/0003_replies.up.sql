@@ -0,0 +1,3 @@
+alter table messages add column if not exists reply_to integer default null references messages (id);
+
+create index if not exists reply_to_idx on messages (reply_to);
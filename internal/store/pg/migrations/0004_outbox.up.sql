@@ -0,0 +1,6 @@
+create table if not exists outbox (
+    id bigserial primary key,
+    type varchar(64) not null,
+    payload jsonb not null,
+    created_at timestamp with time zone not null default current_timestamp
+);
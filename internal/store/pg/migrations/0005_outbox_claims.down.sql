@@ -0,0 +1,5 @@
+drop index if exists outbox_available_idx;
+
+alter table outbox drop column if exists failed_at;
+alter table outbox drop column if exists available_at;
+alter table outbox drop column if exists attempts;
@@ -0,0 +1,5 @@
+alter table outbox add column if not exists attempts integer not null default 0;
+alter table outbox add column if not exists available_at timestamp with time zone not null default current_timestamp;
+alter table outbox add column if not exists failed_at timestamp with time zone default null;
+
+create index if not exists outbox_available_idx on outbox (available_at) where failed_at is null;
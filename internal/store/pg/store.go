@@ -1,143 +1,23 @@
 package pg
 
 import (
-	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
-	"context"
 	"database/sql"
 	"errors"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store/sqlstore"
 	"github.com/jackc/pgerrcode"
 	"github.com/jackc/pgx/v5/pgconn"
-	"time"
 )
 
-type Store struct {
-	conn *sql.DB
-}
-
-func NewStore(conn *sql.DB) *Store {
-	return &Store{
-		conn: conn,
-	}
-}
-
-func (s Store) Bootstrap(ctx context.Context) error {
-	tx, err := s.conn.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
-
-	defer tx.Rollback()
-	tx.ExecContext(ctx, `
-		CREATE TABLE users (
-		    id varchar(128) PRIMARY KEY,
-		    username varchar(128)
-		)
-	`)
-
-	tx.ExecContext(ctx, `create unique index sender_idx on users (username)`)
-	tx.ExecContext(ctx, `
-		create table messages (
-		    id serial primary key ,
-		    sender varchar(128),
-		    recipient varchar(128),
-		    payload text,
-		    sent_at timestamp with time zone,
-		    read_at timestamp with time zone DEFAULT NULL
-		)
-	`)
-
-	tx.ExecContext(ctx, `create index recipient_idx on messages (recipient)`)
-	return tx.Commit()
-}
-
-func (s Store) FindRecipient(ctx context.Context, username string) (userID string, err error) {
-	row := s.conn.QueryRowContext(ctx, `select id from users where username = $1`, username)
-	err = row.Scan(&userID)
-	return
-}
-
-func (s Store) ListMessages(ctx context.Context, userID string) ([]store.Message, error) {
-	rows, err := s.conn.QueryContext(ctx, `
-		select 
-		    m.id,
-		    u.username as sender,
-		    m.sent_at
-		from messages m 
-		join users u on m.sender = u.id
-		where 
-		    m.recipient = $1
-	`, userID)
-
-	if err != nil {
-		return nil, err
-	}
-
-	defer rows.Close()
-
-	var messages []store.Message
-	for rows.Next() {
-		var m store.Message
-		if err := rows.Scan(&m.ID, &m.Sender, &m.Time); err != nil {
-			return nil, err
-		}
-
-		messages = append(messages, m)
-	}
-
-	if err := rows.Err(); err != nil {
-		return nil, err
-	}
-
-	return messages, nil
-}
-
-func (s Store) GetMessage(ctx context.Context, id int64) (*store.Message, error) {
-	row := s.conn.QueryRowContext(ctx, `
-		select 
-		    m.id,
-		    u.username as sender,
-		    m.payload,
-		    m.sent_at
-		from messages m 
-		join users u on m.sender = u.id
-		where 
-		    m.id = $1
-	`, id)
-
-	var msg store.Message
-	err := row.Scan(&msg.ID, &msg.Sender, &msg.Payload, &msg.Time)
-	if err != nil {
-		return nil, err
-	}
-
-	return &msg, nil
-}
-
-func (s Store) RegisterUser(ctx context.Context, userID, username string) error {
-	_, err := s.conn.ExecContext(ctx, `
-		insert into users
-		(id, username)
-		values 
-		($1, $2)
-	`, userID, username)
-
-	if err != nil {
+// dialect — особенности Postgres для общего хранилища.
+var dialect = sqlstore.Dialect{
+	IsConflict: func(err error) bool {
 		var pgErr *pgconn.PgError
-		if errors.As(err, &pgErr) && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code) {
-			err = store.ErrConflict
-		}
-	}
-
-	return err
+		return errors.As(err, &pgErr) && pgerrcode.IsIntegrityConstraintViolation(pgErr.Code)
+	},
 }
 
-func (s Store) SaveMessage(ctx context.Context, userID string, msg store.Message) error {
-	_, err := s.conn.ExecContext(ctx, `
-		insert into messages
-		(sender, recipient, payload, sent_at)
-		values 
-		($1, $2, $3, $4);
-	`, msg.Sender, userID, msg.Payload, time.Now())
-
-	return err
+// NewStore возвращает хранилище на базе Postgres.
+func NewStore(conn *sql.DB) *sqlstore.Store {
+	return sqlstore.New(conn, dialect)
 }
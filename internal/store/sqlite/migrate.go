@@ -0,0 +1,23 @@
+package sqlite
+
+import (
+	"database/sql"
+	"embed"
+	"io/fs"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/migrate"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// NewMigrator возвращает Migrator со схемой хранилища.
+// Блокировка не нужна: SQLite сама не пускает второго писателя.
+func NewMigrator(conn *sql.DB) (*migrate.Migrator, error) {
+	fsys, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return migrate.New(conn, fsys, nil)
+}
@@ -0,0 +1,20 @@
+create table if not exists users (
+    id varchar(128) primary key,
+    username varchar(128)
+);
+
+create unique index if not exists sender_idx on users (username);
+
+create table if not exists messages (
+    id integer primary key autoincrement,
+    sender varchar(128),
+    recipient varchar(128),
+    payload text,
+    sent_at timestamp,
+    read_at timestamp default null,
+    deleted_at timestamp default null,
+    reply_to integer default null references messages (id)
+);
+
+create index if not exists recipient_idx on messages (recipient);
+create index if not exists reply_to_idx on messages (reply_to);
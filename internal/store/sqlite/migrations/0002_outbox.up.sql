@@ -0,0 +1,6 @@
+create table if not exists outbox (
+    id integer primary key autoincrement,
+    type varchar(64) not null,
+    payload text not null,
+    created_at timestamp not null
+);
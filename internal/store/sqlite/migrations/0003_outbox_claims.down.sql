@@ -0,0 +1,5 @@
+drop index if exists outbox_available_idx;
+
+alter table outbox drop column failed_at;
+alter table outbox drop column available_at;
+alter table outbox drop column attempts;
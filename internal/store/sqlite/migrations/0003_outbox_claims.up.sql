@@ -0,0 +1,7 @@
+-- available_at, как и остальное время, хранится строкой в UTC;
+-- пустая строка меньше любого времени, и старые события доступны сразу
+alter table outbox add column attempts integer not null default 0;
+alter table outbox add column available_at timestamp not null default '';
+alter table outbox add column failed_at timestamp default null;
+
+create index if not exists outbox_available_idx on outbox (available_at) where failed_at is null;
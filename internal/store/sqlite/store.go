@@ -0,0 +1,34 @@
+// Package sqlite хранит пользователей и сообщения в SQLite.
+// Подходит для небольших установок на одном сервере.
+package sqlite
+
+import (
+	"database/sql"
+	"errors"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store/sqlstore"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// DSN возвращает строку подключения к файлу базы path.
+// Без внешних ключей SQLite не проверяет reply_to,
+// без busy_timeout сразу отвечает ошибкой на занятую другим соединением базу.
+func DSN(path string) string {
+	return "file:" + path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"
+}
+
+// dialect — особенности SQLite для общего хранилища.
+var dialect = sqlstore.Dialect{
+	IsConflict: func(err error) bool {
+		// младший байт расширенного кода ошибки — основной код SQLite
+		var sqliteErr *sqlite.Error
+		return errors.As(err, &sqliteErr) && sqliteErr.Code()&0xff == sqlite3.SQLITE_CONSTRAINT
+	},
+	OffsetNeedsLimit: true,
+}
+
+// NewStore возвращает хранилище на базе SQLite.
+func NewStore(conn *sql.DB) *sqlstore.Store {
+	return sqlstore.New(conn, dialect)
+}
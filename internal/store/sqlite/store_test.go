@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store/sqlstore"
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store/storetest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStore(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) store.Store {
+		return newTestStore(t)
+	})
+}
+
+func newTestStore(t *testing.T) *sqlstore.Store {
+	t.Helper()
+
+	conn, err := sql.Open("sqlite", DSN(filepath.Join(t.TempDir(), "skill.db")))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	// как и в навыке: SQLite не допускает параллельной записи
+	conn.SetMaxOpenConns(1)
+
+	migrator, err := NewMigrator(conn)
+	require.NoError(t, err)
+	_, err = migrator.Up(context.Background())
+	require.NoError(t, err)
+
+	// повторный запуск не должен ничего применять
+	applied, err := migrator.Up(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, applied)
+
+	return NewStore(conn)
+}
+
+// TestMigrations проверяет, что схема откатывается и применяется заново:
+// down-миграции удаляют столбцы, а SQLite умеет это только с версии 3.35.
+func TestMigrations(t *testing.T) {
+	ctx := context.Background()
+	conn, err := sql.Open("sqlite", DSN(filepath.Join(t.TempDir(), "skill.db")))
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+
+	migrator, err := NewMigrator(conn)
+	require.NoError(t, err)
+
+	applied, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, applied)
+
+	reverted, err := migrator.Down(ctx, len(applied))
+	require.NoError(t, err)
+	assert.Len(t, reverted, len(applied))
+
+	v, err := migrator.Version(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, v)
+
+	again, err := migrator.Up(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, applied, again)
+}
@@ -0,0 +1,488 @@
+// Package sqlstore хранит пользователей и сообщения в базе через database/sql.
+// Запросы общие для всех баз, различия описывает Dialect.
+package sqlstore
+
+import (
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Dialect — особенности базы, которые не скрывают общие запросы.
+type Dialect struct {
+	// IsConflict сообщает, что ошибка — нарушение ограничения целостности.
+	IsConflict func(err error) bool
+	// OffsetNeedsLimit означает, что база не принимает offset без limit,
+	// и тогда «без ограничения» передаётся как limit -1.
+	OffsetNeedsLimit bool
+}
+
+type Store struct {
+	conn    *sql.DB
+	dialect Dialect
+}
+
+func New(conn *sql.DB, dialect Dialect) *Store {
+	return &Store{
+		conn:    conn,
+		dialect: dialect,
+	}
+}
+
+func (s Store) FindRecipient(ctx context.Context, username string) (userID string, err error) {
+	row := s.conn.QueryRowContext(ctx, `select id from users where username = $1`, username)
+	err = row.Scan(&userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = store.ErrNotFound
+	}
+	return
+}
+
+func (s Store) ListMessages(ctx context.Context, userID string, opts store.ListOptions) ([]store.Message, error) {
+	query, args := s.listQuery(userID, opts)
+	rows, err := s.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return scanMessages(rows)
+}
+
+func (s Store) ListUnread(ctx context.Context, userID string) ([]store.Message, error) {
+	return s.ListMessages(ctx, userID, store.ListOptions{UnreadOnly: true})
+}
+
+// listQuery строит запрос ListMessages. Сообщения упорядочены по времени отправки,
+// а при равном времени — по идентификатору, чтобы страницы не пересекались.
+// Если сообщения-курсора opts.After нет, выборка пустая.
+func (s Store) listQuery(userID string, opts store.ListOptions) (string, []interface{}) {
+	args := []interface{}{userID}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	var b strings.Builder
+	b.WriteString(`
+		select 
+		    m.id,
+		    u.username as sender,
+		    m.sent_at
+		from messages m 
+		join users u on m.sender = u.id
+		where 
+		    m.recipient = $1
+		    and m.deleted_at is null`)
+
+	if opts.UnreadOnly {
+		b.WriteString(" and m.read_at is null")
+	}
+	if opts.Sender != "" {
+		fmt.Fprintf(&b, " and u.username = %s", arg(opts.Sender))
+	}
+
+	cmp, dir := ">", "asc"
+	if opts.Order == store.OrderDesc {
+		cmp, dir = "<", "desc"
+	}
+	if opts.After != 0 {
+		fmt.Fprintf(&b, " and (m.sent_at, m.id) %s (select sent_at, id from messages where id = %s)", cmp, arg(opts.After))
+	}
+
+	fmt.Fprintf(&b, " order by m.sent_at %s, m.id %s", dir, dir)
+	if opts.Limit > 0 {
+		fmt.Fprintf(&b, " limit %s", arg(opts.Limit))
+	} else if opts.Offset > 0 && s.dialect.OffsetNeedsLimit {
+		b.WriteString(" limit -1")
+	}
+	if opts.Offset > 0 {
+		fmt.Fprintf(&b, " offset %s", arg(opts.Offset))
+	}
+
+	return b.String(), args
+}
+
+func scanMessages(rows *sql.Rows) ([]store.Message, error) {
+	defer rows.Close()
+
+	var messages []store.Message
+	for rows.Next() {
+		var m store.Message
+		if err := rows.Scan(&m.ID, &m.Sender, &m.Time); err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func (s Store) GetMessage(ctx context.Context, userID string, id int64) (*store.Message, error) {
+	row := s.conn.QueryRowContext(ctx, `
+		select 
+		    m.id,
+		    u.username as sender,
+		    m.payload,
+		    m.sent_at,
+		    coalesce(m.reply_to, 0),
+		    m.recipient
+		from messages m 
+		join users u on m.sender = u.id
+		where 
+		    m.id = $1
+		    and m.deleted_at is null
+	`, id)
+
+	var msg store.Message
+	var recipient string
+	err := row.Scan(&msg.ID, &msg.Sender, &msg.Payload, &msg.Time, &msg.ReplyTo, &recipient)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, store.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if recipient != userID {
+		return nil, store.ErrForbidden
+	}
+
+	return &msg, nil
+}
+
+// checkAccess объясняет, почему запрос, в условие которого входит проверка
+// доступа, не нашёл сообщение: его нет (ErrNotFound) или оно чужое (ErrForbidden).
+// Если allowSender равен true, доступ есть и у отправителя.
+// Возвращает nil, если сообщение есть и доступно.
+func (s Store) checkAccess(ctx context.Context, userID string, id int64, allowSender bool) error {
+	row := s.conn.QueryRowContext(ctx, `
+		select sender, recipient
+		from messages
+		where 
+		    id = $1
+		    and deleted_at is null
+	`, id)
+
+	var sender, recipient string
+	err := row.Scan(&sender, &recipient)
+	if errors.Is(err, sql.ErrNoRows) {
+		return store.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	if recipient != userID && !(allowSender && sender == userID) {
+		return store.ErrForbidden
+	}
+
+	return nil
+}
+
+func (s Store) RegisterUser(ctx context.Context, userID, username string) error {
+	_, err := s.conn.ExecContext(ctx, `
+		insert into users
+		(id, username)
+		values 
+		($1, $2)
+	`, userID, username)
+
+	if err != nil && s.dialect.IsConflict(err) {
+		err = store.ErrConflict
+	}
+
+	return err
+}
+
+// SaveMessage сохраняет сообщение и в той же транзакции записывает
+// событие EventMessageSaved в outbox.
+func (s Store) SaveMessage(ctx context.Context, userID string, msg store.Message) error {
+	tx, err := s.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	sentAt := now()
+	replyTo := sql.NullInt64{Int64: msg.ReplyTo, Valid: msg.ReplyTo != 0}
+
+	row := tx.QueryRowContext(ctx, `
+		insert into messages
+		(sender, recipient, payload, sent_at, reply_to)
+		values 
+		($1, $2, $3, $4, $5)
+		returning id
+	`, msg.Sender, userID, msg.Payload, sentAt, replyTo)
+
+	var id int64
+	if err := row.Scan(&id); err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(store.MessageSaved{
+		MessageID: id,
+		Sender:    msg.Sender,
+		Recipient: userID,
+		Text:      msg.Payload,
+		ReplyTo:   msg.ReplyTo,
+		SentAt:    sentAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		insert into outbox
+		(type, payload, created_at)
+		values 
+		($1, $2, $3)
+	`, store.EventMessageSaved, string(payload), sentAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ClaimEvents забирает события одним запросом. Условие повторяется
+// и снаружи подзапроса: экземпляр навыка, который ждал блокировки строки,
+// перепроверяет его и пропускает событие, уже забранное другим.
+// SQLite и вовсе выполняет запросы на запись по одному.
+func (s Store) ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]store.Event, error) {
+	claimedAt := now()
+	rows, err := s.conn.QueryContext(ctx, `
+		update outbox
+		set attempts = attempts + 1, available_at = $2
+		where 
+		    id in (
+		        select id
+		        from outbox
+		        where failed_at is null and available_at <= $1
+		        order by id
+		        limit $3
+		    )
+		    and failed_at is null
+		    and available_at <= $1
+		returning id, type, payload, created_at, attempts
+	`, claimedAt, claimedAt.Add(lease), limit)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var events []store.Event
+	for rows.Next() {
+		var e store.Event
+		var payload []byte
+		if err := rows.Scan(&e.ID, &e.Type, &payload, &e.CreatedAt, &e.Attempts); err != nil {
+			return nil, err
+		}
+
+		e.Payload = payload
+		events = append(events, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// returning не сохраняет порядок подзапроса
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	return events, nil
+}
+
+func (s Store) AckEvent(ctx context.Context, id int64) error {
+	_, err := s.conn.ExecContext(ctx, `delete from outbox where id = $1`, id)
+	return err
+}
+
+func (s Store) RetryEvent(ctx context.Context, id int64, retryAt time.Time) error {
+	_, err := s.conn.ExecContext(ctx, `update outbox set available_at = $2 where id = $1`, id, retryAt.UTC())
+	return err
+}
+
+func (s Store) FailEvent(ctx context.Context, id int64) error {
+	_, err := s.conn.ExecContext(ctx, `update outbox set failed_at = $2 where id = $1`, id, now())
+	return err
+}
+
+// ListThread находит начало переписки, поднимаясь по reply_to, и возвращает
+// его вместе со всеми ответами в порядке отправки.
+// Доступ проверяется в том же запросе: переписку начинают, только если
+// пользователь отправил или получил сообщение id.
+func (s Store) ListThread(ctx context.Context, userID string, id int64) ([]store.Message, error) {
+	rows, err := s.conn.QueryContext(ctx, `
+		with recursive 
+		ancestors as (
+		    select id, reply_to 
+		    from messages 
+		    where 
+		        id = $1 
+		        and deleted_at is null 
+		        and (recipient = $2 or sender = $2)
+		    union all
+		    select m.id, m.reply_to from messages m join ancestors a on m.id = a.reply_to
+		),
+		thread as (
+		    select id from ancestors where reply_to is null
+		    union all
+		    select m.id from messages m join thread t on m.reply_to = t.id
+		)
+		select 
+		    m.id,
+		    u.username as sender,
+		    m.payload,
+		    m.sent_at,
+		    coalesce(m.reply_to, 0)
+		from messages m 
+		join thread t on m.id = t.id
+		join users u on m.sender = u.id
+		where 
+		    m.deleted_at is null
+		order by m.sent_at
+	`, id, userID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var messages []store.Message
+	for rows.Next() {
+		var m store.Message
+		if err := rows.Scan(&m.ID, &m.Sender, &m.Payload, &m.Time, &m.ReplyTo); err != nil {
+			return nil, err
+		}
+
+		messages = append(messages, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(messages) == 0 {
+		if err := s.checkAccess(ctx, userID, id, true); err != nil {
+			return nil, err
+		}
+	}
+
+	return messages, nil
+}
+
+// MarkRead проверяет получателя в условии обновления. Если ничего не обновилось,
+// сообщение уже прочитано, удалено или адресовано другому — это выясняет checkAccess.
+func (s Store) MarkRead(ctx context.Context, userID string, id int64) error {
+	res, err := s.conn.ExecContext(ctx, `
+		update messages
+		set read_at = $3
+		where 
+		    id = $1
+		    and recipient = $2
+		    and deleted_at is null
+		    and read_at is null
+	`, id, userID, now())
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	// повторное прочтение доступного сообщения — не ошибка
+	if affected == 0 {
+		return s.checkAccess(ctx, userID, id, false)
+	}
+
+	return nil
+}
+
+// DeleteMessage помечает сообщение удалённым. Получатель проверяется в условии
+// обновления, поэтому чужое сообщение не удалить, даже если оно изменилось
+// между запросами.
+func (s Store) DeleteMessage(ctx context.Context, userID string, id int64) error {
+	res, err := s.conn.ExecContext(ctx, `
+		update messages
+		set deleted_at = $3
+		where 
+		    id = $1
+		    and recipient = $2
+		    and deleted_at is null
+	`, id, userID, now())
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		if err := s.checkAccess(ctx, userID, id, false); err != nil {
+			return err
+		}
+
+		// удалённое сообщение не восстанавливается, так что сюда попасть нельзя;
+		// на всякий случай считаем, что сообщения нет
+		return store.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s Store) DeleteRead(ctx context.Context, userID string) (int64, error) {
+	res, err := s.conn.ExecContext(ctx, `
+		update messages
+		set deleted_at = $2
+		where 
+		    recipient = $1
+		    and read_at is not null
+		    and deleted_at is null
+	`, userID, now())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+func (s Store) DeleteAll(ctx context.Context, userID string) (int64, error) {
+	res, err := s.conn.ExecContext(ctx, `
+		update messages
+		set deleted_at = $2
+		where 
+		    recipient = $1
+		    and deleted_at is null
+	`, userID, now())
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// now возвращает текущее время в UTC: SQLite хранит время строкой,
+// и сравнение и сортировка строк верны только для одного часового пояса.
+func now() time.Time {
+	return time.Now().UTC()
+}
@@ -0,0 +1,46 @@
+package sqlstore
+
+import (
+	"strings"
+	"testing"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListQueryLimit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		dialect  Dialect
+		opts     store.ListOptions
+		suffix   string
+		argCount int
+	}{
+		{name: "all", opts: store.ListOptions{}, suffix: "m.id asc", argCount: 1},
+		{name: "limit", opts: store.ListOptions{Limit: 3}, suffix: " limit $2", argCount: 2},
+		{name: "page", opts: store.ListOptions{Limit: 3, Offset: 6}, suffix: " limit $2 offset $3", argCount: 3},
+		{name: "offset", opts: store.ListOptions{Offset: 6}, suffix: "m.id asc offset $2", argCount: 2},
+		{
+			name:     "offset_needs_limit",
+			dialect:  Dialect{OffsetNeedsLimit: true},
+			opts:     store.ListOptions{Offset: 6},
+			suffix:   " limit -1 offset $2",
+			argCount: 2,
+		},
+		{
+			name:     "limit_without_offset",
+			dialect:  Dialect{OffsetNeedsLimit: true},
+			opts:     store.ListOptions{Limit: 3},
+			suffix:   "m.id asc limit $2",
+			argCount: 2,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			query, args := New(nil, tc.dialect).listQuery("user", tc.opts)
+			assert.True(t, strings.HasSuffix(query, tc.suffix), query)
+			assert.Len(t, args, tc.argCount)
+		})
+	}
+}
@@ -2,23 +2,123 @@ package store
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"time"
 )
 
-var ErrConflict = errors.New("data conflict")
+var (
+	ErrConflict = errors.New("data conflict")
+	// ErrNotFound — запрошенного пользователя или сообщения нет, либо сообщение удалено.
+	ErrNotFound = errors.New("not found")
+	// ErrForbidden — сообщение существует, но адресовано другому пользователю.
+	ErrForbidden = errors.New("forbidden")
+)
 
 type Store interface {
+	// FindRecipient возвращает идентификатор пользователя по имени или ErrNotFound.
 	FindRecipient(ctx context.Context, username string) (userId string, err error)
-	ListMessages(ctx context.Context, userID string) ([]Message, error)
-	GetMessage(ctx context.Context, id int64) (*Message, error)
+	// ListMessages возвращает неудалённые сообщения пользователя, выбранные по opts.
+	ListMessages(ctx context.Context, userID string, opts ListOptions) ([]Message, error)
+	// ListUnread возвращает сообщения пользователя, которые он ещё не прочитал.
+	ListUnread(ctx context.Context, userID string) ([]Message, error)
+	// GetMessage возвращает сообщение, адресованное пользователю userID.
+	// Возвращает ErrNotFound, если сообщения нет, и ErrForbidden, если оно адресовано другому.
+	GetMessage(ctx context.Context, userID string, id int64) (*Message, error)
 	SaveMessage(ctx context.Context, userID string, msg Message) error
+	// MarkRead отмечает сообщение, адресованное пользователю userID, прочитанным.
+	// Повторный вызов не меняет время прочтения.
+	MarkRead(ctx context.Context, userID string, id int64) error
+	// DeleteMessage удаляет сообщение, адресованное пользователю userID.
+	// Ошибки такие же, как у GetMessage.
+	DeleteMessage(ctx context.Context, userID string, id int64) error
+	// DeleteRead удаляет все прочитанные сообщения пользователя и возвращает их количество.
+	DeleteRead(ctx context.Context, userID string) (int64, error)
+	// DeleteAll удаляет все сообщения пользователя и возвращает их количество.
+	DeleteAll(ctx context.Context, userID string) (int64, error)
+	// ListThread возвращает переписку, в которую входит сообщение id:
+	// исходное сообщение и все ответы на него в порядке отправки.
+	// Пользователь userID должен быть отправителем или получателем сообщения id.
+	ListThread(ctx context.Context, userID string, id int64) ([]Message, error)
 	RegisterUser(ctx context.Context, userID, username string) error
 }
 
+// Order — порядок сообщений по времени отправки.
+type Order int
+
+const (
+	// OrderAsc — сначала старые сообщения.
+	OrderAsc Order = iota
+	// OrderDesc — сначала новые сообщения.
+	OrderDesc
+)
+
+// ListOptions — параметры выборки ListMessages.
+// Нулевое значение выбирает все сообщения, начиная со старых.
+type ListOptions struct {
+	// Limit ограничивает число сообщений, 0 — без ограничения.
+	Limit int
+	// Offset пропускает первые сообщения выборки.
+	Offset int
+	// After — курсор: выбрать сообщения, идущие в порядке Order после сообщения
+	// с этим идентификатором. Удобнее Offset, когда список меняется между запросами.
+	After int64
+	Order Order
+	// UnreadOnly оставляет только непрочитанные сообщения.
+	UnreadOnly bool
+	// Sender оставляет только сообщения от пользователя с этим именем.
+	Sender string
+}
+
+// Outbox — очередь событий, записанных в одной транзакции с изменением данных.
+// Её реализуют хранилища, события из неё доставляет outbox.Dispatcher.
+// Очередь могут разбирать несколько экземпляров навыка одновременно.
+type Outbox interface {
+	// ClaimEvents забирает до limit событий, которые пора доставить, в порядке создания
+	// и на время lease прячет их от других экземпляров навыка. Событие, которое
+	// за это время не подтвердили и не отложили, будет забрано снова.
+	// Каждый раз увеличивает Event.Attempts.
+	ClaimEvents(ctx context.Context, limit int, lease time.Duration) ([]Event, error)
+	// AckEvent подтверждает доставку события и удаляет его из очереди.
+	AckEvent(ctx context.Context, id int64) error
+	// RetryEvent откладывает недоставленное событие до retryAt.
+	RetryEvent(ctx context.Context, id int64, retryAt time.Time) error
+	// FailEvent выводит событие из очереди, но оставляет его в хранилище
+	// для разбора: доставить его так и не удалось.
+	FailEvent(ctx context.Context, id int64) error
+}
+
+// Типы событий в Outbox.
+const (
+	// EventMessageSaved — пользователю отправлено новое сообщение, содержимое — MessageSaved.
+	EventMessageSaved = "message.saved"
+)
+
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+	// Attempts — сколько раз событие забирали из очереди, включая текущий.
+	Attempts int `json:"-"`
+}
+
+// MessageSaved — содержимое события EventMessageSaved.
+// Sender и Recipient — идентификаторы пользователей Алисы.
+type MessageSaved struct {
+	MessageID int64     `json:"message_id"`
+	Sender    string    `json:"sender"`
+	Recipient string    `json:"recipient"`
+	Text      string    `json:"text"`
+	ReplyTo   int64     `json:"reply_to,omitempty"`
+	SentAt    time.Time `json:"sent_at"`
+}
+
 type Message struct {
 	ID      int64
 	Sender  string
 	Time    time.Time
 	Payload string
+	// ReplyTo — идентификатор сообщения, на которое это сообщение отвечает.
+	ReplyTo int64
 }
@@ -0,0 +1,528 @@
+// Package storetest содержит общие тесты поведения store.Store.
+// Каждая реализация хранилища запускает их у себя:
+//
+//	func TestStore(t *testing.T) {
+//		storetest.Run(t, func(t *testing.T) store.Store {
+//			return NewStore()
+//		})
+//	}
+package storetest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Factory возвращает пустое хранилище для одного теста.
+type Factory func(t *testing.T) store.Store
+
+// Run проверяет, что хранилище ведёт себя так, как ожидают обработчики навыка.
+func Run(t *testing.T, newStore Factory) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, s store.Store)
+	}{
+		{"register_user", testRegisterUser},
+		{"find_recipient", testFindRecipient},
+		{"list_messages", testListMessages},
+		{"list_options", testListOptions},
+		{"list_unread", testListUnread},
+		{"get_message", testGetMessage},
+		{"mark_read", testMarkRead},
+		{"delete_message", testDeleteMessage},
+		{"delete_read", testDeleteRead},
+		{"delete_all", testDeleteAll},
+		{"list_thread", testListThread},
+		{"concurrent_register", testConcurrentRegister},
+		{"concurrent_save", testConcurrentSave},
+		{"outbox", testOutbox},
+		{"outbox_concurrent_claims", testOutboxConcurrentClaims},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.test(t, newStore(t))
+		})
+	}
+}
+
+// пользователи, от имени которых работают тесты
+const (
+	masha = "masha-id"
+	petya = "petya-id"
+	vasya = "vasya-id"
+)
+
+// register регистрирует пользователей masha, petya и vasya.
+func register(t *testing.T, s store.Store) {
+	t.Helper()
+
+	for id, username := range map[string]string{masha: "маша", petya: "петя", vasya: "вася"} {
+		require.NoError(t, s.RegisterUser(context.Background(), id, username))
+	}
+}
+
+// send отправляет сообщение и возвращает его идентификатор.
+func send(t *testing.T, s store.Store, from, to, text string, replyTo int64) int64 {
+	t.Helper()
+
+	ctx := context.Background()
+	require.NoError(t, s.SaveMessage(ctx, to, store.Message{Sender: from, Payload: text, ReplyTo: replyTo}))
+
+	messages, err := s.ListMessages(ctx, to, store.ListOptions{})
+	require.NoError(t, err)
+	require.NotEmpty(t, messages)
+
+	return messages[len(messages)-1].ID
+}
+
+func ids(messages []store.Message) []int64 {
+	result := make([]int64, 0, len(messages))
+	for _, m := range messages {
+		result = append(result, m.ID)
+	}
+
+	return result
+}
+
+func testRegisterUser(t *testing.T, s store.Store) {
+	ctx := context.Background()
+
+	require.NoError(t, s.RegisterUser(ctx, masha, "маша"))
+	assert.ErrorIs(t, s.RegisterUser(ctx, petya, "маша"), store.ErrConflict, "duplicate username")
+	assert.ErrorIs(t, s.RegisterUser(ctx, masha, "мария"), store.ErrConflict, "duplicate user id")
+	assert.NoError(t, s.RegisterUser(ctx, petya, "петя"))
+}
+
+func testFindRecipient(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	register(t, s)
+
+	id, err := s.FindRecipient(ctx, "маша")
+	require.NoError(t, err)
+	assert.Equal(t, masha, id)
+
+	_, err = s.FindRecipient(ctx, "коля")
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func testListMessages(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	register(t, s)
+
+	messages, err := s.ListMessages(ctx, masha, store.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	first := send(t, s, petya, masha, "Привет", 0)
+	send(t, s, masha, petya, "Не мне", 0)
+	second := send(t, s, vasya, masha, "Как дела?", 0)
+	third := send(t, s, petya, masha, "Ау", 0)
+
+	messages, err = s.ListMessages(ctx, masha, store.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{first, second, third}, ids(messages), "messages are ordered by time")
+	assert.Equal(t, "петя", messages[0].Sender, "sender is a username")
+	assert.Equal(t, "вася", messages[1].Sender)
+	assert.False(t, messages[0].Time.IsZero())
+	assert.False(t, messages[1].Time.Before(messages[0].Time))
+}
+
+func testListOptions(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	register(t, s)
+
+	var all []int64
+	for i := 0; i < 5; i++ {
+		from := petya
+		if i%2 == 1 {
+			from = vasya
+		}
+		all = append(all, send(t, s, from, masha, fmt.Sprintf("Сообщение %d", i), 0))
+	}
+	require.NoError(t, s.MarkRead(ctx, masha, all[0]))
+	require.NoError(t, s.MarkRead(ctx, masha, all[3]))
+
+	reversed := []int64{all[4], all[3], all[2], all[1], all[0]}
+
+	tests := []struct {
+		name string
+		opts store.ListOptions
+		want []int64
+	}{
+		{"all", store.ListOptions{}, all},
+		{"limit", store.ListOptions{Limit: 2}, all[:2]},
+		{"offset", store.ListOptions{Offset: 3}, all[3:]},
+		{"page", store.ListOptions{Limit: 2, Offset: 2}, all[2:4]},
+		{"offset_past_end", store.ListOptions{Offset: 10}, nil},
+		{"desc", store.ListOptions{Order: store.OrderDesc}, reversed},
+		{"desc_page", store.ListOptions{Order: store.OrderDesc, Limit: 2, Offset: 1}, reversed[1:3]},
+		{"after", store.ListOptions{After: all[1], Limit: 2}, all[2:4]},
+		{"after_desc", store.ListOptions{After: all[3], Order: store.OrderDesc}, reversed[2:]},
+		{"after_last", store.ListOptions{After: all[4]}, nil},
+		{"unread_only", store.ListOptions{UnreadOnly: true}, []int64{all[1], all[2], all[4]}},
+		{"sender", store.ListOptions{Sender: "вася"}, []int64{all[1], all[3]}},
+		{"sender_unread", store.ListOptions{Sender: "вася", UnreadOnly: true}, []int64{all[1]}},
+		{"unknown_sender", store.ListOptions{Sender: "коля"}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages, err := s.ListMessages(ctx, masha, tt.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, nilIfEmpty(ids(messages)))
+		})
+	}
+}
+
+// nilIfEmpty позволяет сравнивать пустые выборки с nil в таблицах тестов.
+func nilIfEmpty(ids []int64) []int64 {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	return ids
+}
+
+func testListUnread(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	register(t, s)
+
+	first := send(t, s, petya, masha, "Привет", 0)
+	second := send(t, s, vasya, masha, "Как дела?", 0)
+	require.NoError(t, s.MarkRead(ctx, masha, first))
+
+	messages, err := s.ListUnread(ctx, masha)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{second}, ids(messages))
+
+	messages, err = s.ListUnread(ctx, petya)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func testGetMessage(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	register(t, s)
+
+	id := send(t, s, petya, masha, "Привет", 0)
+	reply := send(t, s, masha, petya, "И тебе привет", id)
+
+	msg, err := s.GetMessage(ctx, masha, id)
+	require.NoError(t, err)
+	assert.Equal(t, id, msg.ID)
+	assert.Equal(t, "петя", msg.Sender)
+	assert.Equal(t, "Привет", msg.Payload)
+	assert.Zero(t, msg.ReplyTo)
+
+	msg, err = s.GetMessage(ctx, petya, reply)
+	require.NoError(t, err)
+	assert.Equal(t, id, msg.ReplyTo)
+
+	_, err = s.GetMessage(ctx, petya, id)
+	assert.ErrorIs(t, err, store.ErrForbidden, "sender cannot read a message as a recipient")
+	_, err = s.GetMessage(ctx, vasya, id)
+	assert.ErrorIs(t, err, store.ErrForbidden)
+	_, err = s.GetMessage(ctx, masha, reply+100)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+}
+
+func testMarkRead(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	register(t, s)
+
+	id := send(t, s, petya, masha, "Привет", 0)
+
+	assert.ErrorIs(t, s.MarkRead(ctx, vasya, id), store.ErrForbidden)
+	assert.ErrorIs(t, s.MarkRead(ctx, masha, id+100), store.ErrNotFound)
+
+	unread, err := s.ListUnread(ctx, masha)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{id}, ids(unread), "forbidden attempt does not mark the message read")
+
+	require.NoError(t, s.MarkRead(ctx, masha, id))
+	require.NoError(t, s.MarkRead(ctx, masha, id), "marking twice is not an error")
+
+	messages, err := s.ListUnread(ctx, masha)
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+}
+
+func testDeleteMessage(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	register(t, s)
+
+	id := send(t, s, petya, masha, "Привет", 0)
+	other := send(t, s, petya, masha, "Ещё привет", 0)
+
+	assert.ErrorIs(t, s.DeleteMessage(ctx, petya, id), store.ErrForbidden, "sender cannot delete recipient's message")
+	require.NoError(t, s.DeleteMessage(ctx, masha, id))
+	assert.ErrorIs(t, s.DeleteMessage(ctx, masha, id), store.ErrNotFound, "message is already deleted")
+	assert.ErrorIs(t, s.MarkRead(ctx, masha, id), store.ErrNotFound, "deleted message cannot be marked read")
+
+	_, err := s.GetMessage(ctx, masha, id)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	messages, err := s.ListMessages(ctx, masha, store.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{other}, ids(messages))
+}
+
+func testDeleteRead(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	register(t, s)
+
+	read := send(t, s, petya, masha, "Привет", 0)
+	unread := send(t, s, petya, masha, "Ещё привет", 0)
+	send(t, s, masha, petya, "Привет, Петя", 0)
+	require.NoError(t, s.MarkRead(ctx, masha, read))
+
+	n, err := s.DeleteRead(ctx, masha)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	n, err = s.DeleteRead(ctx, masha)
+	require.NoError(t, err)
+	assert.Zero(t, n, "deleted messages are not counted again")
+
+	messages, err := s.ListMessages(ctx, masha, store.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{unread}, ids(messages))
+
+	messages, err = s.ListMessages(ctx, petya, store.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, messages, 1, "other users' messages are kept")
+}
+
+func testDeleteAll(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	register(t, s)
+
+	read := send(t, s, petya, masha, "Привет", 0)
+	send(t, s, vasya, masha, "Как дела?", 0)
+	send(t, s, masha, petya, "Привет, Петя", 0)
+	require.NoError(t, s.MarkRead(ctx, masha, read))
+
+	n, err := s.DeleteAll(ctx, masha)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+
+	n, err = s.DeleteAll(ctx, masha)
+	require.NoError(t, err)
+	assert.Zero(t, n)
+
+	messages, err := s.ListMessages(ctx, masha, store.ListOptions{})
+	require.NoError(t, err)
+	assert.Empty(t, messages)
+
+	messages, err = s.ListMessages(ctx, petya, store.ListOptions{})
+	require.NoError(t, err)
+	assert.Len(t, messages, 1, "other users' messages are kept")
+}
+
+func testListThread(t *testing.T, s store.Store) {
+	ctx := context.Background()
+	register(t, s)
+
+	root := send(t, s, petya, masha, "Пойдём в кино?", 0)
+	reply := send(t, s, masha, petya, "Давай", root)
+	answer := send(t, s, petya, masha, "В семь у входа", reply)
+	send(t, s, petya, masha, "Другая тема", 0)
+
+	for _, id := range []int64{root, reply, answer} {
+		thread, err := s.ListThread(ctx, masha, id)
+		require.NoError(t, err)
+		assert.Equal(t, []int64{root, reply, answer}, ids(thread), "thread of message %d", id)
+	}
+
+	thread, err := s.ListThread(ctx, petya, root)
+	require.NoError(t, err, "sender can read the thread")
+	require.Len(t, thread, 3)
+	assert.Equal(t, "петя", thread[0].Sender)
+	assert.Equal(t, "Пойдём в кино?", thread[0].Payload)
+	assert.Equal(t, "маша", thread[1].Sender)
+	assert.Equal(t, root, thread[1].ReplyTo)
+
+	_, err = s.ListThread(ctx, vasya, root)
+	assert.ErrorIs(t, err, store.ErrForbidden)
+	_, err = s.ListThread(ctx, masha, answer+100)
+	assert.ErrorIs(t, err, store.ErrNotFound)
+
+	require.NoError(t, s.DeleteMessage(ctx, petya, reply))
+	thread, err = s.ListThread(ctx, masha, answer)
+	require.NoError(t, err)
+	assert.Equal(t, []int64{root, answer}, ids(thread), "deleted messages are hidden from the thread")
+}
+
+func testConcurrentRegister(t *testing.T, s store.Store) {
+	const workers = 10
+
+	var registered atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			err := s.RegisterUser(context.Background(), fmt.Sprintf("user-%d", i), "маша")
+			if err == nil {
+				registered.Add(1)
+				return
+			}
+			assert.ErrorIs(t, err, store.ErrConflict)
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(1), registered.Load(), "only one user gets the name")
+}
+
+func testConcurrentSave(t *testing.T, s store.Store) {
+	const workers = 10
+
+	ctx := context.Background()
+	register(t, s)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			msg := store.Message{Sender: petya, Payload: fmt.Sprintf("Сообщение %d", i)}
+			assert.NoError(t, s.SaveMessage(ctx, masha, msg))
+		}(i)
+	}
+	wg.Wait()
+
+	messages, err := s.ListUnread(ctx, masha)
+	require.NoError(t, err)
+	assert.Len(t, messages, workers)
+
+	seen := make(map[int64]bool)
+	for _, m := range messages {
+		assert.False(t, seen[m.ID], "message ids are unique")
+		seen[m.ID] = true
+	}
+}
+
+func testOutbox(t *testing.T, s store.Store) {
+	outbox, ok := s.(store.Outbox)
+	if !ok {
+		t.Skip("store has no outbox")
+	}
+
+	ctx := context.Background()
+	register(t, s)
+
+	events, err := outbox.ClaimEvents(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	first := send(t, s, petya, masha, "Привет", 0)
+	second := send(t, s, masha, petya, "И тебе привет", first)
+
+	limited, err := outbox.ClaimEvents(ctx, 1, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, limited, 1)
+
+	rest, err := outbox.ClaimEvents(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, rest, 1, "claimed events are hidden from other dispatchers")
+
+	events = append(limited, rest...)
+	assert.Less(t, events[0].ID, events[1].ID, "events are ordered by creation")
+	for _, e := range events {
+		assert.Equal(t, store.EventMessageSaved, e.Type)
+		assert.False(t, e.CreatedAt.IsZero())
+		assert.Equal(t, 1, e.Attempts)
+	}
+
+	var saved store.MessageSaved
+	require.NoError(t, json.Unmarshal(events[1].Payload, &saved))
+	assert.Equal(t, second, saved.MessageID)
+	assert.Equal(t, masha, saved.Sender)
+	assert.Equal(t, petya, saved.Recipient)
+	assert.Equal(t, "И тебе привет", saved.Text)
+	assert.Equal(t, first, saved.ReplyTo)
+	assert.False(t, saved.SentAt.IsZero())
+
+	require.NoError(t, outbox.RetryEvent(ctx, events[0].ID, time.Now().Add(-time.Second)))
+	require.NoError(t, outbox.RetryEvent(ctx, events[1].ID, time.Now().Add(time.Hour)))
+
+	retried, err := outbox.ClaimEvents(ctx, 10, 10*time.Millisecond)
+	require.NoError(t, err)
+	require.Len(t, retried, 1, "events wait until retry time")
+	assert.Equal(t, events[0].ID, retried[0].ID)
+	assert.Equal(t, 2, retried[0].Attempts)
+
+	time.Sleep(20 * time.Millisecond)
+	expired, err := outbox.ClaimEvents(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	require.Len(t, expired, 1, "event is claimed again after the lease expires")
+	assert.Equal(t, 3, expired[0].Attempts)
+
+	require.NoError(t, outbox.AckEvent(ctx, events[0].ID))
+	require.NoError(t, outbox.AckEvent(ctx, events[0].ID), "acking twice is not an error")
+
+	require.NoError(t, outbox.RetryEvent(ctx, events[1].ID, time.Now().Add(-time.Second)))
+	require.NoError(t, outbox.FailEvent(ctx, events[1].ID))
+
+	pending, err := outbox.ClaimEvents(ctx, 10, time.Minute)
+	require.NoError(t, err)
+	assert.Empty(t, pending, "failed events are not delivered again")
+}
+
+func testOutboxConcurrentClaims(t *testing.T, s store.Store) {
+	outbox, ok := s.(store.Outbox)
+	if !ok {
+		t.Skip("store has no outbox")
+	}
+
+	ctx := context.Background()
+	register(t, s)
+
+	const total = 20
+	for i := 0; i < total; i++ {
+		send(t, s, petya, masha, fmt.Sprint(i), 0)
+	}
+
+	// несколько экземпляров навыка разбирают очередь одновременно
+	var mu sync.Mutex
+	claimed := make(map[int64]int)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			for {
+				events, err := outbox.ClaimEvents(ctx, 3, time.Minute)
+				if !assert.NoError(t, err) || len(events) == 0 {
+					return
+				}
+
+				mu.Lock()
+				for _, e := range events {
+					claimed[e.ID]++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Len(t, claimed, total)
+	for id, n := range claimed {
+		assert.Equal(t, 1, n, "event %d is claimed once", id)
+	}
+}
@@ -0,0 +1,72 @@
+package templates
+
+// Category — категория множественного числа по CLDR.
+type Category string
+
+const (
+	One   Category = "one"
+	Few   Category = "few"
+	Many  Category = "many"
+	Other Category = "other"
+)
+
+// PluralRule выбирает форму слова для числа по правилам языка.
+type PluralRule struct {
+	// Categories — категории языка в том порядке, в котором
+	// формы слова перечисляются в шаблонах.
+	Categories []Category
+	// Select возвращает категорию целого числа n.
+	Select func(n int) Category
+}
+
+// Russian — правила CLDR для русского языка для целых чисел:
+// one — 1, 21, 101; few — 2–4, 22–24; many — 0, 5–20, 25–30, 11–14.
+// Формы перечисляются как «сообщение», «сообщения», «сообщений».
+var Russian = PluralRule{
+	Categories: []Category{One, Few, Many},
+	Select: func(n int) Category {
+		if n < 0 {
+			n = -n
+		}
+
+		switch mod10, mod100 := n%10, n%100; {
+		case mod10 == 1 && mod100 != 11:
+			return One
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return Few
+		default:
+			return Many
+		}
+	},
+}
+
+// OneOther — правила CLDR для языков, различающих только единственное
+// число: английского, турецкого, казахского. one — 1, other — остальные числа.
+// Формы перечисляются как «message», «messages».
+var OneOther = PluralRule{
+	Categories: []Category{One, Other},
+	Select: func(n int) Category {
+		if n == 1 || n == -1 {
+			return One
+		}
+
+		return Other
+	},
+}
+
+// Form возвращает форму слова для числа n. Если форм меньше, чем категорий,
+// для недостающих используется последняя форма.
+func (r PluralRule) Form(n int, forms ...string) string {
+	if len(forms) == 0 {
+		return ""
+	}
+
+	category := r.Select(n)
+	for i, c := range r.Categories {
+		if c == category && i < len(forms) {
+			return forms[i]
+		}
+	}
+
+	return forms[len(forms)-1]
+}
@@ -0,0 +1,94 @@
+// Package templates собирает ответы навыка из именованных шаблонов фраз.
+//
+// Шаблон фразы — text/template с функциями:
+//
+//	{{plural .Count "сообщение" "сообщения" "сообщений"}} — форма слова для числа;
+//	{{count .Count "сообщение" "сообщения" "сообщений"}}  — число вместе с формой слова.
+//
+// У фразы может быть несколько вариантов, навык выбирает один из них случайно,
+// чтобы не повторять одно и то же слово в слово.
+package templates
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"text/template"
+
+	"bitbucket.org/sotavant/yandex-alice-skill/internal/logger"
+	"go.uber.org/zap"
+)
+
+// Data — параметры шаблона фразы.
+type Data map[string]interface{}
+
+// Picker выбирает номер варианта фразы из n.
+type Picker func(n int) int
+
+// First всегда выбирает первый вариант фразы: ответы предсказуемы, это удобно в тестах.
+func First(int) int {
+	return 0
+}
+
+// Random выбирает вариант фразы случайно.
+func Random(n int) int {
+	return rand.Intn(n)
+}
+
+// Set — шаблоны фраз одного языка.
+type Set struct {
+	plural  PluralRule
+	phrases map[string][]*template.Template
+	pick    Picker
+}
+
+// New разбирает шаблоны фраз: для каждого имени — один или несколько вариантов.
+func New(plural PluralRule, phrases map[string][]string, pick Picker) (*Set, error) {
+	s := &Set{
+		plural:  plural,
+		phrases: make(map[string][]*template.Template, len(phrases)),
+		pick:    pick,
+	}
+
+	funcs := template.FuncMap{
+		"plural": plural.Form,
+		"count": func(n int, forms ...string) string {
+			return fmt.Sprintf("%d %s", n, plural.Form(n, forms...))
+		},
+	}
+
+	for name, variants := range phrases {
+		if len(variants) == 0 {
+			return nil, fmt.Errorf("phrase %q has no variants", name)
+		}
+
+		for i, text := range variants {
+			tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+			if err != nil {
+				return nil, fmt.Errorf("cannot parse variant %d of phrase %q: %w", i, name, err)
+			}
+			s.phrases[name] = append(s.phrases[name], tmpl)
+		}
+	}
+
+	return s, nil
+}
+
+// Render выбирает вариант фразы name и подставляет в него data.
+// Если фразы нет или шаблон не выполнился, возвращает имя фразы:
+// пользователь услышит странный ответ, но навык продолжит работать.
+func (s *Set) Render(name string, data Data) string {
+	variants, ok := s.phrases[name]
+	if !ok {
+		logger.Log.Warn("unknown phrase", zap.String("phrase", name))
+		return name
+	}
+
+	var b strings.Builder
+	if err := variants[s.pick(len(variants))].Execute(&b, data); err != nil {
+		logger.Log.Warn("cannot render phrase", zap.String("phrase", name), zap.Error(err))
+		return name
+	}
+
+	return b.String()
+}
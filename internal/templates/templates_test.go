@@ -0,0 +1,76 @@
+package templates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRussianPlural(t *testing.T) {
+	testCases := []struct {
+		n        int
+		expected Category
+	}{
+		{n: 0, expected: Many},
+		{n: 1, expected: One},
+		{n: 2, expected: Few},
+		{n: 4, expected: Few},
+		{n: 5, expected: Many},
+		{n: 11, expected: Many},
+		{n: 12, expected: Many},
+		{n: 14, expected: Many},
+		{n: 20, expected: Many},
+		{n: 21, expected: One},
+		{n: 22, expected: Few},
+		{n: 25, expected: Many},
+		{n: 101, expected: One},
+		{n: 111, expected: Many},
+		{n: 112, expected: Many},
+		{n: 1001, expected: One},
+		{n: -1, expected: One},
+	}
+
+	for _, tc := range testCases {
+		assert.Equal(t, tc.expected, Russian.Select(tc.n), "n = %d", tc.n)
+	}
+
+	assert.Equal(t, "сообщения", Russian.Form(3, "сообщение", "сообщения", "сообщений"))
+	assert.Equal(t, "шт.", Russian.Form(5, "шт."), "missing forms fall back to the last one")
+}
+
+func TestOneOtherPlural(t *testing.T) {
+	assert.Equal(t, One, OneOther.Select(1))
+	assert.Equal(t, Other, OneOther.Select(0))
+	assert.Equal(t, Other, OneOther.Select(21))
+	assert.Equal(t, "messages", OneOther.Form(2, "message", "messages"))
+}
+
+func TestRender(t *testing.T) {
+	s, err := New(Russian, map[string][]string{
+		"unread":  {`Для вас {{count .Count "новое сообщение" "новых сообщения" "новых сообщений"}}.`},
+		"deleted": {`{{if .Count}}Удалено {{.Count}} {{plural .Count "сообщение" "сообщения" "сообщений"}}.{{else}}Нечего удалять.{{end}}`},
+		"hello":   {"Привет!", "Здравствуйте!"},
+	}, First)
+	require.NoError(t, err)
+
+	assert.Equal(t, "Для вас 1 новое сообщение.", s.Render("unread", Data{"Count": 1}))
+	assert.Equal(t, "Для вас 3 новых сообщения.", s.Render("unread", Data{"Count": 3}))
+	assert.Equal(t, "Для вас 21 новое сообщение.", s.Render("unread", Data{"Count": 21}))
+	assert.Equal(t, "Удалено 12 сообщений.", s.Render("deleted", Data{"Count": 12}))
+	assert.Equal(t, "Нечего удалять.", s.Render("deleted", Data{"Count": 0}))
+
+	assert.Equal(t, "Привет!", s.Render("hello", nil))
+	assert.Equal(t, "missing", s.Render("missing", nil), "unknown phrase renders as its name")
+	assert.Equal(t, "unread", s.Render("unread", Data{}), "missing parameter renders as phrase name")
+
+	last := func(n int) int { return n - 1 }
+	s, err = New(Russian, map[string][]string{"hello": {"Привет!", "Здравствуйте!"}}, last)
+	require.NoError(t, err)
+	assert.Equal(t, "Здравствуйте!", s.Render("hello", nil))
+
+	_, err = New(Russian, map[string][]string{"broken": {"{{.Count"}}, First)
+	assert.Error(t, err)
+	_, err = New(Russian, map[string][]string{"empty": {}}, First)
+	assert.Error(t, err)
+}
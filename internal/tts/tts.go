@@ -0,0 +1,44 @@
+package tts
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SoundBell — звук колокольчика из библиотеки Алисы.
+const SoundBell = "alice-sounds-things-bell-1.opus"
+
+// Builder собирает строку для поля tts ответа: текст, паузы и звуки.
+type Builder struct {
+	parts []string
+}
+
+func New() *Builder {
+	return &Builder{}
+}
+
+// Text добавляет произносимый текст.
+func (b *Builder) Text(s string) *Builder {
+	if s = strings.TrimSpace(s); s != "" {
+		b.parts = append(b.parts, s)
+	}
+
+	return b
+}
+
+// Pause добавляет паузу заданной длительности.
+func (b *Builder) Pause(d time.Duration) *Builder {
+	b.parts = append(b.parts, fmt.Sprintf("sil <[%d]>", d.Milliseconds()))
+	return b
+}
+
+// Sound добавляет звук из библиотеки Алисы или загруженный в навык.
+func (b *Builder) Sound(id string) *Builder {
+	b.parts = append(b.parts, fmt.Sprintf(`<speaker audio="%s">`, id))
+	return b
+}
+
+func (b *Builder) String() string {
+	return strings.Join(b.parts, " ")
+}
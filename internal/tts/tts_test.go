@@ -0,0 +1,52 @@
+package tts
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuilder(t *testing.T) {
+	testCases := []struct {
+		name     string
+		build    func(b *Builder) *Builder
+		expected string
+	}{
+		{
+			name:     "empty",
+			build:    func(b *Builder) *Builder { return b },
+			expected: "",
+		},
+		{
+			name:     "text",
+			build:    func(b *Builder) *Builder { return b.Text("  Привет!  ") },
+			expected: "Привет!",
+		},
+		{
+			name:     "blank_text",
+			build:    func(b *Builder) *Builder { return b.Text("Привет!").Text("   ") },
+			expected: "Привет!",
+		},
+		{
+			name: "pause",
+			build: func(b *Builder) *Builder {
+				return b.Text("Точное время 12 часов.").Pause(300 * time.Millisecond).Text("Новых сообщений нет.")
+			},
+			expected: "Точное время 12 часов. sil <[300]> Новых сообщений нет.",
+		},
+		{
+			name: "sound",
+			build: func(b *Builder) *Builder {
+				return b.Sound(SoundBell).Text("Для вас 1 новое сообщение.")
+			},
+			expected: `<speaker audio="alice-sounds-things-bell-1.opus"> Для вас 1 новое сообщение.`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.build(New()).String())
+		})
+	}
+}